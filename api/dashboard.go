@@ -0,0 +1,92 @@
+package api
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"barcode-music-player/player"
+)
+
+//go:embed web
+var webFiles embed.FS
+
+// statusInterval is how often the event stream sends the playback status.
+const statusInterval = 5 * time.Second
+
+// dashboard serves the web UI. The files are public; the UI asks for the API
+// token and uses it for every API call.
+func dashboard() http.Handler {
+	files, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServerFS(files)
+}
+
+// handleEvents streams pipeline events and the playback status as
+// Server-Sent Events. Browsers can't set headers on an EventSource, so the
+// token is passed as a query parameter.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	events, unsubscribe := s.player.Subscribe()
+	defer unsubscribe()
+
+	send := func(name string, v interface{}) bool {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	sendStatus := func() bool {
+		status, err := s.player.Status(r.Context())
+		if err != nil {
+			return send("status-error", map[string]string{"error": err.Error()})
+		}
+		return send("status", status)
+	}
+
+	if !sendStatus() {
+		return
+	}
+
+	ticker := time.NewTicker(statusInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if !sendStatus() {
+				return
+			}
+		case event, ok := <-events:
+			if !ok || !send(event.Type, event) {
+				return
+			}
+			// A finished scan has probably changed what's playing
+			if event.Type == player.EventScan && !sendStatus() {
+				return
+			}
+		}
+	}
+}
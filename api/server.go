@@ -0,0 +1,253 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"barcode-music-player/config"
+	"barcode-music-player/input"
+	"barcode-music-player/mappings"
+	"barcode-music-player/player"
+)
+
+// Server exposes the player over HTTP, along with a web dashboard. Every API
+// request must carry the API token, either as "Authorization: Bearer <token>"
+// or as a "token" query parameter.
+type Server struct {
+	addr   string
+	token  string
+	player *player.Player
+	// Scans and commands run under this rather than the request context, so
+	// that they finish even if the client goes away
+	scans context.Context
+}
+
+func NewServer(addr, token string, p *player.Player) *Server {
+	return &Server{
+		addr:   addr,
+		token:  token,
+		player: p,
+		scans:  context.Background(),
+	}
+}
+
+// Handler returns the API routes and the dashboard.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	mux.HandleFunc("POST /api/scan", s.handleScan)
+	mux.HandleFunc("GET /api/resolve", s.handleResolve)
+	mux.HandleFunc("GET /api/status", s.handleStatus)
+	mux.HandleFunc("GET /api/scans", s.handleScans)
+	mux.HandleFunc("GET /api/stats", s.handleStats)
+	mux.HandleFunc("GET /api/stats/report", s.handleReport)
+	mux.HandleFunc("POST /api/commands/{command}", s.handleCommand)
+	mux.HandleFunc("GET /api/mappings", s.handleListMappings)
+	mux.HandleFunc("GET /api/mappings/{barcode}", s.handleGetMapping)
+	mux.HandleFunc("PUT /api/mappings/{barcode}", s.handlePutMapping)
+	mux.HandleFunc("DELETE /api/mappings/{barcode}", s.handleDeleteMapping)
+
+	root := http.NewServeMux()
+	root.Handle("/api/", s.authenticate(mux))
+	root.Handle("/", dashboard())
+	return root
+}
+
+// Run serves the API until ctx is cancelled. Scans and commands in progress
+// are cancelled along with scans.
+func (s *Server) Run(ctx, scans context.Context) error {
+	s.scans = scans
+	server := &http.Server{
+		Addr:    s.addr,
+		Handler: s.Handler(),
+		// Ends open event streams on shutdown
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("failed to start API server: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			token = bearer
+		}
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid or missing API token"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type scanRequest struct {
+	Barcode string `json:"barcode"`
+	// DryRun resolves the barcode and reports what would be played without
+	// playing it
+	DryRun bool `json:"dry_run"`
+}
+
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	var req scanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON body: %w", err))
+		return
+	}
+
+	barcode := strings.TrimSpace(req.Barcode)
+	if barcode == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("barcode is required"))
+		return
+	}
+
+	event := input.Event{
+		Barcode:   barcode,
+		Source:    "api",
+		Timestamp: time.Now(),
+	}
+
+	scan := s.player.Scan
+	if req.DryRun {
+		scan = s.player.DryRun
+	}
+
+	result, err := scan(s.scans, event)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, result)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleResolve(w http.ResponseWriter, r *http.Request) {
+	barcode := strings.TrimSpace(r.URL.Query().Get("barcode"))
+	if barcode == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("barcode is required"))
+		return
+	}
+
+	res, err := s.player.Resolve(r.Context(), barcode, "api")
+	if res == nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	// The release is known but not on Spotify: report what was found, with
+	// the error
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, res)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := s.player.Status(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) handleScans(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.player.RecentScans())
+}
+
+func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request) {
+	command := r.PathValue("command")
+	if !config.IsCommand(command) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown command %q", command))
+		return
+	}
+
+	if err := s.player.RunCommand(s.scans, command); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"mode": s.player.Mode()})
+}
+
+func (s *Server) handleListMappings(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.player.Mappings().List())
+}
+
+func (s *Server) handleGetMapping(w http.ResponseWriter, r *http.Request) {
+	mapping, ok := s.player.Mappings().Get(r.PathValue("barcode"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no mapping for barcode %s", r.PathValue("barcode")))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapping)
+}
+
+func (s *Server) handlePutMapping(w http.ResponseWriter, r *http.Request) {
+	var mapping mappings.Mapping
+	if err := json.NewDecoder(r.Body).Decode(&mapping); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON body: %w", err))
+		return
+	}
+	mapping.Barcode = r.PathValue("barcode")
+
+	if err := mapping.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.player.Mappings().Set(mapping); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mapping)
+}
+
+func (s *Server) handleDeleteMapping(w http.ResponseWriter, r *http.Request) {
+	barcode := r.PathValue("barcode")
+	if _, ok := s.player.Mappings().Get(barcode); !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no mapping for barcode %s", barcode))
+		return
+	}
+
+	if err := s.player.Mappings().Delete(barcode); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
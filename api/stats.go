@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"barcode-music-player/history"
+	"barcode-music-player/stats"
+)
+
+// computeStats computes the statistics for the period in the query: since,
+// until or month, and optionally profile, top and stale_days.
+func (s *Server) computeStats(query url.Values) (*stats.Stats, stats.Options, int, error) {
+	log := s.player.History()
+	if log == nil {
+		return nil, stats.Options{}, http.StatusNotFound, fmt.Errorf("the history is disabled")
+	}
+
+	since, until, err := stats.ParsePeriod(query.Get("since"), query.Get("until"), query.Get("month"), time.Now())
+	if err != nil {
+		return nil, stats.Options{}, http.StatusBadRequest, err
+	}
+
+	opts := stats.Options{Since: since, Until: until, Top: 10, StaleAfter: stats.DefaultStaleAfter}
+	if value := query.Get("top"); value != "" {
+		if opts.Top, err = strconv.Atoi(value); err != nil {
+			return nil, opts, http.StatusBadRequest, fmt.Errorf("invalid top: %w", err)
+		}
+	}
+	if value := query.Get("stale_days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 1 {
+			return nil, opts, http.StatusBadRequest, fmt.Errorf("invalid stale_days %q", value)
+		}
+		opts.StaleAfter = time.Duration(days) * 24 * time.Hour
+	}
+
+	entries, err := log.Read(history.Filter{Profile: query.Get("profile")})
+	if err != nil {
+		return nil, opts, http.StatusInternalServerError, err
+	}
+	if store := s.player.Collection(); store != nil {
+		opts.Collection = store.List()
+	}
+
+	return stats.Compute(entries, opts), opts, http.StatusOK, nil
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	result, _, status, err := s.computeStats(r.URL.Query())
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleReport renders the statistics as a Markdown or HTML report, by
+// default for the previous month.
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("month") == "" && query.Get("since") == "" && query.Get("until") == "" {
+		query.Set("month", stats.PreviousMonth(time.Now()))
+	}
+
+	format := query.Get("format")
+	contentType := "text/html; charset=utf-8"
+	switch format {
+	case "", stats.FormatHTML:
+		format = stats.FormatHTML
+	case stats.FormatMarkdown:
+		contentType = "text/markdown; charset=utf-8"
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown format %q, expected %s or %s", format, stats.FormatMarkdown, stats.FormatHTML))
+		return
+	}
+
+	result, opts, status, err := s.computeStats(query)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := stats.WriteReport(&buf, result, format, stats.Title(opts.Since, opts.Until), opts.StaleAfter); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,217 @@
+"use strict";
+
+let token = localStorage.getItem("token") || "";
+let source = null;
+
+const $ = (selector, root = document) => root.querySelector(selector);
+
+async function api(method, path, body) {
+	const options = {
+		method,
+		headers: { Authorization: "Bearer " + token },
+	};
+	if (body !== undefined) {
+		options.headers["Content-Type"] = "application/json";
+		options.body = JSON.stringify(body);
+	}
+
+	const resp = await fetch(path, options);
+	if (resp.status === 401) {
+		logout();
+		throw new Error("invalid API token");
+	}
+	if (resp.status === 204) {
+		return null;
+	}
+	return resp.json();
+}
+
+function login(value) {
+	token = value;
+	localStorage.setItem("token", token);
+	$("#login").hidden = true;
+	$("#app").hidden = false;
+	$("#report").href = "/api/stats/report?token=" + encodeURIComponent(token);
+	loadScans();
+	connect();
+}
+
+function logout() {
+	token = "";
+	localStorage.removeItem("token");
+	if (source) {
+		source.close();
+	}
+	$("#app").hidden = true;
+	$("#login").hidden = false;
+}
+
+function connect() {
+	source = new EventSource("/api/events?token=" + encodeURIComponent(token));
+
+	source.onopen = () => setConnected(true);
+	source.onerror = () => setConnected(false);
+
+	source.addEventListener("status", (e) => renderStatus(JSON.parse(e.data)));
+	source.addEventListener("step", (e) => {
+		const event = JSON.parse(e.data);
+		if (event.scan) {
+			addStep(event.scan, event.message);
+		}
+	});
+	source.addEventListener("scan", (e) => {
+		const event = JSON.parse(e.data);
+		const pending = findPending(event.scan);
+		const item = renderScan(event.result);
+		if (pending) {
+			pending.replaceWith(item);
+		} else {
+			$("#scans").prepend(item);
+		}
+	});
+}
+
+function setConnected(connected) {
+	const badge = $("#connection");
+	badge.textContent = connected ? "live" : "offline";
+	badge.classList.toggle("online", connected);
+}
+
+async function loadScans() {
+	const scans = await api("GET", "/api/scans");
+	const list = $("#scans");
+	list.replaceChildren(...scans.map(renderScan));
+}
+
+function renderStatus(status) {
+	$("#mode").textContent = status.mode;
+
+	const playback = status.playback;
+	const track = playback && playback.item;
+	if (!track) {
+		$("#track").textContent = "Nothing playing";
+		$("#album").textContent = "";
+		$("#device").textContent = "";
+		$("#cover").removeAttribute("src");
+		return;
+	}
+
+	const artists = (track.artists || []).map((a) => a.name).join(", ");
+	$("#track").textContent = (playback.is_playing ? "▶️ " : "⏸️ ") + track.name;
+	$("#album").textContent = track.album ? `${track.album.name} · ${artists}` : artists;
+	$("#device").textContent = playback.device ? "on " + playback.device.name : "";
+
+	const images = (track.album && track.album.images) || [];
+	if (images.length > 0) {
+		$("#cover").src = images[0].url;
+	} else {
+		$("#cover").removeAttribute("src");
+	}
+}
+
+function renderScan(result) {
+	const item = $("#scan-template").content.firstElementChild.cloneNode(true);
+	item.dataset.scan = result.scan;
+
+	let title = result.scan;
+	if (result.command) {
+		title = "Command: " + result.command;
+	} else if (result.album) {
+		title = `${result.album} · ${result.artist}`;
+	}
+
+	$(".scan-title", item).textContent = title;
+	$(".scan-meta", item).textContent = `${result.scan} via ${result.source} · ${new Date(result.time).toLocaleTimeString()}`;
+	$(".scan-steps", item).textContent = (result.steps || []).join("\n");
+
+	if (result.dry_run) {
+		item.classList.add("dry-run");
+		$(".scan-plan", item).textContent = describePlan(result.plan);
+	}
+
+	if (result.error) {
+		item.classList.add("failed");
+		$(".scan-error", item).textContent = result.error;
+		if (!result.command) {
+			setupFix(item, result.scan);
+		}
+	}
+	return item;
+}
+
+function describePlan(plan) {
+	if (!plan) {
+		return "🧪 Dry run";
+	}
+	let text = "🧪 Dry run: would " + plan.action;
+	if (plan.command) {
+		text += " " + plan.command;
+	}
+	if (plan.device) {
+		text += " on " + plan.device.name;
+	}
+	return text;
+}
+
+function findPending(scan) {
+	return Array.from(document.querySelectorAll("#scans .scan.pending")).find((item) => item.dataset.scan === scan);
+}
+
+function addStep(scan, message) {
+	let item = findPending(scan);
+	if (!item) {
+		item = renderScan({ scan, source: "…", time: new Date().toISOString() });
+		item.classList.add("pending");
+		$("details", item).open = true;
+		$("#scans").prepend(item);
+	}
+
+	const steps = $(".scan-steps", item);
+	steps.textContent += (steps.textContent ? "\n" : "") + message;
+}
+
+function setupFix(item, barcode) {
+	const form = $(".fix", item);
+	form.hidden = false;
+	form.addEventListener("submit", async (e) => {
+		e.preventDefault();
+		const data = new FormData(form);
+		try {
+			const result = await api("PUT", "/api/mappings/" + encodeURIComponent(barcode), {
+				album_uri: data.get("album_uri"),
+				note: data.get("note"),
+			});
+			if (result && result.error) {
+				alert(result.error);
+				return;
+			}
+			form.replaceWith(document.createTextNode("✅ Mapping saved, scan again to play it"));
+		} catch (err) {
+			alert(err.message);
+		}
+	});
+}
+
+$("#login").addEventListener("submit", (e) => {
+	e.preventDefault();
+	login(new FormData(e.target).get("token"));
+});
+
+$("#scan").addEventListener("submit", async (e) => {
+	e.preventDefault();
+	const input = $("input[name=barcode]", e.target);
+	const barcode = input.value.trim();
+	const dryRun = $("input[name=dry_run]", e.target).checked;
+	input.value = "";
+	try {
+		await api("POST", "/api/scan", { barcode, dry_run: dryRun });
+	} catch (err) {
+		alert(err.message);
+	}
+});
+
+if (token) {
+	login(token);
+} else {
+	$("#login").hidden = false;
+}
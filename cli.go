@@ -0,0 +1,364 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"barcode-music-player/config"
+	"barcode-music-player/musicbrainz"
+	"barcode-music-player/player"
+	"barcode-music-player/spotify"
+)
+
+// Exit codes shared by all subcommands.
+const (
+	exitOK = 0
+	// exitFailure means the command itself failed
+	exitFailure = 1
+	exitUsage   = 2
+	exitConfig  = 3
+	// exitAuth means there is no usable Spotify login
+	exitAuth = 4
+	// exitNotFound means a barcode couldn't be resolved to an album
+	exitNotFound = 5
+)
+
+// exitError is an error with the exit code it should end the program with.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+func fail(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// command is a subcommand of the CLI.
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	// Assigned here because help refers back to the list
+	commands = []command{
+		{"scan", "", "Scan barcodes interactively (the default)", runScanner},
+		{"serve", "", "Run as a daemon without a terminal", runServe},
+		{"login", "", "Log in to Spotify in the browser", runLogin},
+		{"logout", "", "Forget the stored Spotify login", runLogout},
+		{"whoami", "", "Show the logged in Spotify account", runWhoami},
+		{"devices", "", "List the available Spotify devices", runDevices},
+		{"lookup", "<barcode>", "Resolve a barcode without playing it", runLookup},
+		{"play", "<barcode>", "Resolve a barcode and play it", runPlay},
+		{"mappings", "[list|set|delete] ...", "Manage per-barcode albums and playback options", runMappings},
+		{"collection", "[list|add|tag|export|...]", "Manage and export the catalog of scanned releases", runCollection},
+		{"import", "<file>", "Resolve a list of barcodes into a report, playlist or library", runImport},
+		{"history", "", "Show or export the scan history", runHistory},
+		{"stats", "", "Show listening statistics or a monthly report", runStats},
+		{"doctor", "", "Check the configuration, login and devices", runDoctor},
+		{"help", "", "Show this help", runHelp},
+	}
+}
+
+// run dispatches to a subcommand and returns the exit code.
+func run(args []string) int {
+	name := "scan"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		err := cmd.run(args)
+		if err == nil {
+			return exitOK
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+
+		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+
+		var exit *exitError
+		if errors.As(err, &exit) {
+			return exit.code
+		}
+		return exitFailure
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	printUsage()
+	return exitUsage
+}
+
+func runHelp(args []string) error {
+	printUsage()
+	return nil
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: barcode-music-player [command] [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-30s %s\n", strings.TrimSpace(cmd.name+" "+cmd.args), cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'barcode-music-player <command> -h' for the flags of a command.")
+}
+
+// flags are the flags shared by the subcommands. Configuration flags override
+// the environment and .env.
+type flags struct {
+	*flag.FlagSet
+
+	json      bool
+	profile   string
+	overrides []func(*config.Config) error
+}
+
+func newFlags(name, args string) *flags {
+	f := &flags{FlagSet: flag.NewFlagSet(name, flag.ContinueOnError)}
+	f.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: barcode-music-player %s [flags] %s\n\nFlags:\n", name, args)
+		f.PrintDefaults()
+	}
+
+	f.BoolVar(&f.json, "json", false, "print results as JSON")
+	f.StringVar(&f.profile, "profile", "", "settings profile (PROFILE)")
+
+	f.override("market", "Spotify market, e.g. DE (SPOTIFY_MARKET)", func(c *config.Config, v string) error {
+		c.SpotifyMarket = strings.ToUpper(v)
+		return nil
+	})
+	f.override("mode", "play, queue or catalog (PLAY_MODE)", func(c *config.Config, v string) error {
+		c.PlayMode = v
+		return nil
+	})
+	f.override("rescan", "what to do when rescanning the playing album (RESCAN_POLICY)", func(c *config.Config, v string) error {
+		c.RescanPolicy = v
+		return nil
+	})
+	f.override("edition", "edition preference (EDITION_PREFERENCE)", func(c *config.Config, v string) error {
+		c.EditionPreference = v
+		return nil
+	})
+	f.override("device", "device name or ID to play on (PLAY_DEVICE)", func(c *config.Config, v string) error {
+		c.PlayDevice = v
+		return nil
+	})
+	f.override("shuffle", "shuffle playback, true or false (PLAY_SHUFFLE)", func(c *config.Config, v string) error {
+		shuffle, err := strconv.ParseBool(v)
+		c.PlayShuffle = shuffle
+		return err
+	})
+	f.override("repeat", "off, context or track (PLAY_REPEAT)", func(c *config.Config, v string) error {
+		c.PlayRepeat = v
+		return nil
+	})
+	f.override("volume", "volume percentage (PLAY_VOLUME)", func(c *config.Config, v string) error {
+		volume, err := strconv.Atoi(v)
+		c.PlayVolume = &volume
+		return err
+	})
+	f.override("mappings-file", "mappings file (MAPPINGS_FILE)", func(c *config.Config, v string) error {
+		c.MappingsFile = v
+		return nil
+	})
+	f.overrideBool("dry-run", "resolve scans and show what would be played without playing (DRY_RUN)", func(c *config.Config, v bool) {
+		c.DryRun = v
+	})
+	f.override("api-addr", "control API address, enables the API (API_ADDR)", func(c *config.Config, v string) error {
+		c.APIEnabled = true
+		c.APIAddr = v
+		return nil
+	})
+
+	return f
+}
+
+// override registers a flag that changes a configuration setting.
+func (f *flags) override(name, usage string, apply func(*config.Config, string) error) {
+	f.Func(name, usage, f.overrider(name, apply))
+}
+
+// overrideBool registers a boolean flag that changes a configuration setting.
+func (f *flags) overrideBool(name, usage string, apply func(*config.Config, bool)) {
+	f.BoolFunc(name, usage, f.overrider(name, func(c *config.Config, v string) error {
+		value, err := strconv.ParseBool(v)
+		apply(c, value)
+		return err
+	}))
+}
+
+func (f *flags) overrider(name string, apply func(*config.Config, string) error) func(string) error {
+	return func(value string) error {
+		f.overrides = append(f.overrides, func(c *config.Config) error {
+			if err := apply(c, value); err != nil {
+				return fmt.Errorf("invalid -%s: %w", name, err)
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
+// parse parses the flags and checks the number of positional arguments,
+// unless nargs is negative.
+func (f *flags) parse(args []string, nargs int) error {
+	if err := f.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fail(exitUsage, err)
+	}
+
+	if nargs >= 0 && f.NArg() != nargs {
+		f.Usage()
+		return fail(exitUsage, fmt.Errorf("expected %d argument(s), got %d", nargs, f.NArg()))
+	}
+
+	return nil
+}
+
+// progress returns where progress messages go: stderr with -json, so stdout
+// is only the JSON.
+func (f *flags) progress() io.Writer {
+	if f.json {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// loadConfig loads the configuration with the flag overrides applied.
+func (f *flags) loadConfig() error {
+	if f.profile != "" {
+		os.Setenv("PROFILE", f.profile)
+	}
+
+	loaded, err := config.Load()
+	if err != nil {
+		return fail(exitConfig, err)
+	}
+
+	if err := f.apply(loaded); err != nil {
+		return err
+	}
+
+	cfg = loaded
+	return nil
+}
+
+// reloadConfig reloads the configuration for the serve mode, keeping the flag
+// overrides.
+func (f *flags) reloadConfig() (*config.Config, error) {
+	loaded, err := config.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.apply(loaded); err != nil {
+		return nil, err
+	}
+	return loaded, nil
+}
+
+func (f *flags) apply(c *config.Config) error {
+	for _, override := range f.overrides {
+		if err := override(c); err != nil {
+			return fail(exitUsage, err)
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return fail(exitConfig, err)
+	}
+	return nil
+}
+
+// connect creates the API clients and loads the stored Spotify login.
+// Progress messages go to out.
+func connect(out io.Writer) error {
+	spotifyClient = spotify.NewClient(cfg.SpotifyClientID, cfg.SpotifyClientSecret, cfg.SpotifyRedirectURI)
+	spotifyClient.Output = out
+	musicbrainzClient = musicbrainz.NewClient(cfg.MusicBrainzURL)
+
+	if !spotifyClient.LoadStoredToken() {
+		return fail(exitAuth, fmt.Errorf("not logged in to Spotify, run 'barcode-music-player login' first"))
+	}
+
+	if missing := spotifyClient.MissingScopes(player.SyncScopes(cfg)...); len(missing) > 0 {
+		fmt.Fprintf(out, "⚠️  Warning: The Spotify login lacks %s for syncing, run 'barcode-music-player login' again\n", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// setMarket only searches for albums that can be played in the user's
+// country.
+func setMarket(out io.Writer) {
+	spotifyClient.Market = cfg.SpotifyMarket
+	if spotifyClient.Market == "" {
+		if err := spotifyClient.DetectMarket(context.Background()); err != nil {
+			fmt.Fprintf(out, "⚠️  Warning: Could not detect your Spotify market: %v\n", err)
+		}
+	}
+	if spotifyClient.Market != "" {
+		fmt.Fprintf(out, "🌍 Using Spotify market: %s\n", spotifyClient.Market)
+	}
+}
+
+// newPlayer connects to Spotify and MusicBrainz and creates the player, which
+// prints its progress to out.
+func newPlayer(out io.Writer) (*player.Player, error) {
+	if err := connect(out); err != nil {
+		return nil, err
+	}
+	setMarket(out)
+
+	p, err := player.New(cfg, spotifyClient, musicbrainzClient, out)
+	if err != nil {
+		return nil, fail(exitConfig, err)
+	}
+	return p, nil
+}
+
+// resolveExitCode returns exitNotFound for errors meaning the scan has no
+// album, and exitFailure otherwise.
+func resolveExitCode(err error) int {
+	switch {
+	case errors.Is(err, musicbrainz.ErrNotFound),
+		errors.Is(err, player.ErrNotOnSpotify),
+		errors.Is(err, spotify.ErrRegionRestricted):
+		return exitNotFound
+	}
+	return exitFailure
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
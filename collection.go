@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"barcode-music-player/collection"
+	"barcode-music-player/input"
+)
+
+func runCollection(args []string) error {
+	f := newFlags("collection", "[list | show <barcode> | add <barcode> | tag [flags] <barcode> | delete <barcode> | export [flags]]")
+	if err := f.parse(args, -1); err != nil {
+		return err
+	}
+
+	if err := f.loadConfig(); err != nil {
+		return err
+	}
+
+	action := "list"
+	if f.NArg() > 0 {
+		action = f.Arg(0)
+	}
+
+	// Adding looks the release up, so it needs the player
+	if action == "add" {
+		return addToCollection(f.Args()[1:], f.progress(), f.json)
+	}
+
+	store, err := collection.Open(cfg.CollectionFile)
+	if err != nil {
+		return fail(exitConfig, err)
+	}
+
+	switch action {
+	case "list":
+		return listCollection(store, f.json)
+	case "show":
+		if f.NArg() != 2 {
+			return fail(exitUsage, fmt.Errorf("usage: barcode-music-player collection show <barcode>"))
+		}
+		item, ok := store.Get(f.Arg(1))
+		if !ok {
+			return fail(exitNotFound, fmt.Errorf("%s is not in the collection", f.Arg(1)))
+		}
+		if f.json {
+			return printJSON(item)
+		}
+		printItem(item)
+		return nil
+	case "tag":
+		return tagItem(store, f.Args()[1:], f.json)
+	case "delete":
+		if f.NArg() != 2 {
+			return fail(exitUsage, fmt.Errorf("usage: barcode-music-player collection delete <barcode>"))
+		}
+		if _, ok := store.Get(f.Arg(1)); !ok {
+			return fail(exitNotFound, fmt.Errorf("%s is not in the collection", f.Arg(1)))
+		}
+		if err := store.Delete(f.Arg(1)); err != nil {
+			return err
+		}
+		fmt.Printf("🗑️  Removed %s from the collection\n", f.Arg(1))
+		return nil
+	case "export":
+		return exportCollection(store, f.Args()[1:])
+	}
+
+	f.Usage()
+	return fail(exitUsage, fmt.Errorf("unknown collection action %q", action))
+}
+
+func listCollection(store *collection.Store, asJSON bool) error {
+	list := store.List()
+	if asJSON {
+		return printJSON(list)
+	}
+
+	if len(list) == 0 {
+		fmt.Printf("No releases in %s\n", store.Path())
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BARCODE\tARTIST\tTITLE\tYEAR\tFORMAT\tSHELF\tCONDITION")
+	for _, item := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", item.Barcode, item.Artist, item.Title, year(item.Year), item.Format, item.Shelf, item.Condition)
+	}
+	return w.Flush()
+}
+
+func printItem(item collection.Item) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fields := []struct{ name, value string }{
+		{"Barcode", item.Barcode},
+		{"Title", item.Title},
+		{"Artist", item.Artist},
+		{"Year", year(item.Year)},
+		{"Format", item.Format},
+		{"Label", item.Label},
+		{"Catalog number", item.CatalogNumber},
+		{"Country", item.Country},
+		{"Shelf", item.Shelf},
+		{"Condition", collection.Conditions[item.Condition]},
+		{"Notes", item.Notes},
+		{"MusicBrainz", "https://musicbrainz.org/release/" + item.ReleaseID},
+		{"Spotify", item.AlbumURI},
+		{"Cover", item.CoverURL},
+		{"Added", item.AddedAt.Local().Format("2006-01-02 15:04")},
+		{"Scans", strconv.Itoa(item.Scans)},
+	}
+	if item.DiscogsID != "" {
+		fields = append(fields, struct{ name, value string }{"Discogs", "https://www.discogs.com/release/" + item.DiscogsID})
+	}
+
+	for _, field := range fields {
+		if field.value != "" {
+			fmt.Fprintf(w, "%s:\t%s\n", field.name, field.value)
+		}
+	}
+	w.Flush()
+}
+
+func year(y int) string {
+	if y == 0 {
+		return ""
+	}
+	return strconv.Itoa(y)
+}
+
+// addToCollection catalogs a barcode as if it was scanned in catalog mode.
+// Progress messages go to out.
+func addToCollection(args []string, out io.Writer, asJSON bool) error {
+	if len(args) != 1 {
+		return fail(exitUsage, fmt.Errorf("usage: barcode-music-player collection add <barcode>"))
+	}
+	if !cfg.CollectionEnabled {
+		return fail(exitConfig, fmt.Errorf("the collection is disabled, set COLLECTION_ENABLED=true to catalog releases"))
+	}
+
+	p, err := newPlayer(out)
+	if err != nil {
+		return err
+	}
+
+	result, err := p.Catalog(context.Background(), input.Event{
+		Barcode:   args[0],
+		Source:    "cli",
+		Timestamp: time.Now(),
+	})
+
+	if asJSON {
+		if printErr := printJSON(result); printErr != nil {
+			return printErr
+		}
+	}
+
+	if err != nil {
+		return fail(resolveExitCode(err), err)
+	}
+	return nil
+}
+
+// tagItem sets the shelf, condition or notes of a release in the collection.
+// Fields that aren't given keep their current value.
+func tagItem(store *collection.Store, args []string, asJSON bool) error {
+	fs := flag.NewFlagSet("collection tag", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: barcode-music-player collection tag [flags] <barcode>\n\nFlags:")
+		fs.PrintDefaults()
+	}
+
+	shelf := fs.String("shelf", "", "where the release is kept")
+	condition := fs.String("condition", "", "M, NM, VG+, VG, G+, G, F or P")
+	notes := fs.String("notes", "", "notes for yourself")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fail(exitUsage, err)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fail(exitUsage, fmt.Errorf("expected a barcode"))
+	}
+
+	item, ok := store.Get(fs.Arg(0))
+	if !ok {
+		return fail(exitNotFound, fmt.Errorf("%s is not in the collection, scan it in catalog mode or run 'barcode-music-player collection add %s'", fs.Arg(0), fs.Arg(0)))
+	}
+
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "shelf":
+			item.Shelf = *shelf
+		case "condition":
+			item.Condition = *condition
+		case "notes":
+			item.Notes = *notes
+		}
+	})
+
+	if err := item.Validate(); err != nil {
+		return fail(exitUsage, err)
+	}
+
+	if err := store.Set(item); err != nil {
+		return err
+	}
+
+	if asJSON {
+		return printJSON(item)
+	}
+	fmt.Printf("🏷️  Tagged %s\n", item.Barcode)
+	return nil
+}
+
+// exportCollection writes the collection to standard output.
+func exportCollection(store *collection.Store, args []string) error {
+	fs := flag.NewFlagSet("collection export", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: barcode-music-player collection export [flags]\n\nFlags:")
+		fs.PrintDefaults()
+	}
+
+	format := fs.String("format", "csv", "csv, json or discogs")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fail(exitUsage, err)
+	}
+
+	items := store.List()
+	switch *format {
+	case "csv":
+		return collection.WriteCSV(os.Stdout, items)
+	case "json":
+		return printJSON(items)
+	case "discogs":
+		skipped, err := collection.WriteDiscogsCSV(os.Stdout, items)
+		if err != nil {
+			return err
+		}
+		if len(skipped) > 0 {
+			fmt.Fprintf(os.Stderr, "⚠️  %d release(s) without a Discogs link in MusicBrainz were left out:\n", len(skipped))
+			for _, item := range skipped {
+				fmt.Fprintf(os.Stderr, "   %s: %s · %s\n", item.Barcode, item.Title, item.Artist)
+			}
+		}
+		return nil
+	}
+
+	return fail(exitUsage, fmt.Errorf("unknown export format %q, expected csv, json or discogs", *format))
+}
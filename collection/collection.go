@@ -0,0 +1,232 @@
+package collection
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"barcode-music-player/musicbrainz"
+)
+
+// Conditions, graded like Discogs' Goldmine standard.
+var Conditions = map[string]string{
+	"M":   "Mint (M)",
+	"NM":  "Near Mint (NM or M-)",
+	"VG+": "Very Good Plus (VG+)",
+	"VG":  "Very Good (VG)",
+	"G+":  "Good Plus (G+)",
+	"G":   "Good (G)",
+	"F":   "Fair (F)",
+	"P":   "Poor (P)",
+}
+
+// Item is a physical release in the collection, keyed by its barcode or, for
+// CDs identified by their table of contents, its disc ID.
+type Item struct {
+	Barcode   string `json:"barcode"`
+	ReleaseID string `json:"release_id"`
+	Title     string `json:"title"`
+	Artist    string `json:"artist"`
+
+	Format        string `json:"format,omitempty"`
+	Label         string `json:"label,omitempty"`
+	CatalogNumber string `json:"catalog_number,omitempty"`
+	Year          int    `json:"year,omitempty"`
+	Country       string `json:"country,omitempty"`
+	CoverURL      string `json:"cover_url,omitempty"`
+	DiscogsID     string `json:"discogs_id,omitempty"`
+	AlbumURI      string `json:"album_uri,omitempty"`
+
+	// Shelf is where the release is kept, Condition one of the Conditions
+	// keys
+	Shelf     string `json:"shelf,omitempty"`
+	Condition string `json:"condition,omitempty"`
+	Notes     string `json:"notes,omitempty"`
+
+	AddedAt     time.Time `json:"added_at"`
+	LastScanned time.Time `json:"last_scanned"`
+	Scans       int       `json:"scans"`
+}
+
+// FromRelease describes a release scanned as barcode.
+func FromRelease(barcode string, release *musicbrainz.Release) Item {
+	return Item{
+		Barcode:       barcode,
+		ReleaseID:     release.ID,
+		Title:         release.Title,
+		Artist:        release.GetMainArtist(),
+		Format:        release.Format(),
+		Label:         release.Label(),
+		CatalogNumber: release.CatalogNumber(),
+		Year:          release.Year(),
+		Country:       release.Country,
+		CoverURL:      release.CoverURL(),
+		DiscogsID:     release.DiscogsID(),
+	}
+}
+
+// Validate checks the fields that can be edited.
+func (i Item) Validate() error {
+	if strings.TrimSpace(i.Barcode) == "" {
+		return fmt.Errorf("barcode is required")
+	}
+	if i.Condition != "" {
+		if _, ok := Conditions[i.Condition]; !ok {
+			return fmt.Errorf("invalid condition %q, expected one of M, NM, VG+, VG, G+, G, F or P", i.Condition)
+		}
+	}
+	return nil
+}
+
+// Store is a JSON file of the collection keyed by barcode.
+type Store struct {
+	path string
+
+	mu    sync.Mutex
+	items map[string]Item
+}
+
+// DefaultPath returns the collection file next to the stored Spotify token.
+func DefaultPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".barcode-music-player-collection.json")
+}
+
+// Open loads the collection file at path. A missing file is an empty
+// collection.
+func Open(path string) (*Store, error) {
+	if path == "" {
+		path = DefaultPath()
+	}
+
+	s := &Store{
+		path:  path,
+		items: make(map[string]Item),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collection: %w", err)
+	}
+
+	var list []Item
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse collection %s: %w", path, err)
+	}
+
+	for _, item := range list {
+		s.items[item.Barcode] = item
+	}
+
+	return s, nil
+}
+
+// Path returns the file the store is saved to.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Get returns the item for a barcode.
+func (s *Store) Get(barcode string) (Item, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[barcode]
+	return item, ok
+}
+
+// List returns all items sorted by artist, year and title.
+func (s *Store) List() []Item {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sorted()
+}
+
+// Record adds a scanned release to the collection, or counts another scan of
+// one already in it. The release metadata is updated, while the shelf,
+// condition and notes are kept.
+func (s *Store) Record(scanned Item, at time.Time) (Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := scanned
+	if existing, ok := s.items[scanned.Barcode]; ok {
+		item.Shelf, item.Condition, item.Notes = existing.Shelf, existing.Condition, existing.Notes
+		item.AddedAt, item.Scans = existing.AddedAt, existing.Scans
+		if item.AlbumURI == "" {
+			item.AlbumURI = existing.AlbumURI
+		}
+	} else {
+		item.AddedAt = at
+	}
+	item.LastScanned = at
+	item.Scans++
+
+	s.items[item.Barcode] = item
+	return item, s.save()
+}
+
+// Set replaces an item, such as after tagging it.
+func (s *Store) Set(item Item) error {
+	if err := item.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items[item.Barcode] = item
+	return s.save()
+}
+
+// Delete removes an item. Deleting an unknown barcode is not an error.
+func (s *Store) Delete(barcode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.items, barcode)
+	return s.save()
+}
+
+func (s *Store) sorted() []Item {
+	list := make([]Item, 0, len(s.items))
+	for _, item := range s.items {
+		list = append(list, item)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if !strings.EqualFold(a.Artist, b.Artist) {
+			return strings.ToLower(a.Artist) < strings.ToLower(b.Artist)
+		}
+		if a.Year != b.Year {
+			return a.Year < b.Year
+		}
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		return a.Barcode < b.Barcode
+	})
+	return list
+}
+
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.sorted(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal collection: %w", err)
+	}
+
+	if err := os.WriteFile(s.path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to save collection: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package collection
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{
+	"barcode", "artist", "title", "format", "label", "catalog_number", "year", "country",
+	"shelf", "condition", "notes", "release_id", "discogs_id", "album_uri", "cover_url",
+	"added_at", "scans",
+}
+
+// WriteCSV writes the items as CSV with a header row.
+func WriteCSV(w io.Writer, items []Item) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		year := ""
+		if item.Year > 0 {
+			year = strconv.Itoa(item.Year)
+		}
+
+		record := []string{
+			item.Barcode, item.Artist, item.Title, item.Format, item.Label, item.CatalogNumber, year, item.Country,
+			item.Shelf, item.Condition, item.Notes, item.ReleaseID, item.DiscogsID, item.AlbumURI, item.CoverURL,
+			item.AddedAt.Format(time.RFC3339), strconv.Itoa(item.Scans),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// discogsHeader are the columns of a Discogs collection export, which its
+// CSV import accepts back. Discogs matches rows by release_id.
+var discogsHeader = []string{
+	"Catalog#", "Artist", "Title", "Label", "Format", "Rating", "Released", "release_id",
+	"CollectionFolder", "Date Added", "Collection Media Condition", "Collection Sleeve Condition",
+	"Collection Notes",
+}
+
+// WriteDiscogsCSV writes the items in Discogs' collection CSV format. Items
+// without a Discogs release aren't written; they are returned so they can be
+// reported.
+func WriteDiscogsCSV(w io.Writer, items []Item) (skipped []Item, err error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(discogsHeader); err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if item.DiscogsID == "" {
+			skipped = append(skipped, item)
+			continue
+		}
+
+		year := ""
+		if item.Year > 0 {
+			year = strconv.Itoa(item.Year)
+		}
+
+		notes := item.Notes
+		if item.Shelf != "" {
+			notes = "Shelf: " + item.Shelf
+			if item.Notes != "" {
+				notes += "; " + item.Notes
+			}
+		}
+
+		record := []string{
+			item.CatalogNumber, item.Artist, item.Title, item.Label, item.Format, "", year, item.DiscogsID,
+			"Uncategorized", item.AddedAt.Format("2006-01-02 15:04:05"), Conditions[item.Condition], "",
+			notes,
+		}
+		if err := writer.Write(record); err != nil {
+			return skipped, err
+		}
+	}
+
+	writer.Flush()
+	return skipped, writer.Error()
+}
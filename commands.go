@@ -0,0 +1,538 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"barcode-music-player/collection"
+	"barcode-music-player/history"
+	"barcode-music-player/input"
+	"barcode-music-player/mappings"
+	"barcode-music-player/spotify"
+	"barcode-music-player/stats"
+)
+
+func runLogin(args []string) error {
+	f := newFlags("login", "")
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+
+	if err := f.loadConfig(); err != nil {
+		return err
+	}
+
+	out := f.progress()
+	spotifyClient = spotify.NewClient(cfg.SpotifyClientID, cfg.SpotifyClientSecret, cfg.SpotifyRedirectURI)
+	spotifyClient.Output = out
+	if err := loginSpotify(out); err != nil {
+		return fail(exitAuth, fmt.Errorf("authentication failed: %w", err))
+	}
+
+	fmt.Fprintln(out, "✅ Successfully authenticated with Spotify!")
+	return printUser(f.json)
+}
+
+func runLogout(args []string) error {
+	f := newFlags("logout", "")
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+
+	// The stored token doesn't depend on the configuration
+	if err := spotify.NewClient("", "", "").DeleteStoredToken(); err != nil {
+		return fmt.Errorf("failed to remove stored token: %w", err)
+	}
+
+	fmt.Println("👋 Logged out of Spotify")
+	return nil
+}
+
+func runWhoami(args []string) error {
+	f := newFlags("whoami", "")
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+
+	if err := f.loadConfig(); err != nil {
+		return err
+	}
+	if err := connect(f.progress()); err != nil {
+		return err
+	}
+
+	return printUser(f.json)
+}
+
+func printUser(asJSON bool) error {
+	user, err := spotifyClient.GetCurrentUser(context.Background())
+	if err != nil {
+		return fail(exitAuth, err)
+	}
+
+	if asJSON {
+		return printJSON(user)
+	}
+
+	fmt.Printf("👤 %s (%s)\n", user.DisplayName, user.ID)
+	fmt.Printf("   Country: %s\n", user.Country)
+	fmt.Printf("   Plan:    %s\n", user.Product)
+	return nil
+}
+
+func runDevices(args []string) error {
+	f := newFlags("devices", "")
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+
+	if err := f.loadConfig(); err != nil {
+		return err
+	}
+	if err := connect(f.progress()); err != nil {
+		return err
+	}
+
+	devices, err := spotifyClient.GetAvailableDevices(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if f.json {
+		return printJSON(devices)
+	}
+
+	if len(devices) == 0 {
+		fmt.Println("No Spotify devices found, open Spotify on the device you want to play on")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tVOLUME\tACTIVE\tID")
+	for _, device := range devices {
+		active := ""
+		if device.IsActive {
+			active = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d%%\t%s\t%s\n", device.Name, device.Type, device.VolumePercent, active, device.ID)
+	}
+	return w.Flush()
+}
+
+func runLookup(args []string) error {
+	f := newFlags("lookup", "<barcode>")
+	// The resolution is always printed as JSON
+	f.json = true
+	if err := f.parse(args, 1); err != nil {
+		return err
+	}
+
+	if err := f.loadConfig(); err != nil {
+		return err
+	}
+
+	// The result is always JSON, so progress messages always go to stderr
+	p, err := newPlayer(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	res, err := p.Resolve(context.Background(), f.Arg(0), "cli")
+	if res == nil {
+		return fail(resolveExitCode(err), err)
+	}
+
+	// A release known but not on Spotify is printed with the error
+	if printErr := printJSON(res); printErr != nil {
+		return printErr
+	}
+	if err != nil {
+		return fail(resolveExitCode(err), err)
+	}
+	return nil
+}
+
+func runPlay(args []string) error {
+	f := newFlags("play", "<barcode>")
+	if err := f.parse(args, 1); err != nil {
+		return err
+	}
+
+	if err := f.loadConfig(); err != nil {
+		return err
+	}
+
+	p, err := newPlayer(f.progress())
+	if err != nil {
+		return err
+	}
+
+	result, err := p.Scan(context.Background(), input.Event{
+		Barcode:   f.Arg(0),
+		Source:    "cli",
+		Timestamp: time.Now(),
+	})
+
+	if f.json {
+		if printErr := printJSON(result); printErr != nil {
+			return printErr
+		}
+	}
+
+	if err != nil {
+		return fail(resolveExitCode(err), err)
+	}
+	return nil
+}
+
+func runMappings(args []string) error {
+	f := newFlags("mappings", "[list | set [flags] <barcode> | delete <barcode>]")
+	if err := f.parse(args, -1); err != nil {
+		return err
+	}
+
+	if err := f.loadConfig(); err != nil {
+		return err
+	}
+
+	store, err := mappings.Open(cfg.MappingsFile)
+	if err != nil {
+		return fail(exitConfig, err)
+	}
+
+	action := "list"
+	if f.NArg() > 0 {
+		action = f.Arg(0)
+	}
+
+	switch action {
+	case "list":
+		return listMappings(store, f.json)
+	case "set":
+		return setMapping(store, f.Args()[1:], f.json)
+	case "delete":
+		if f.NArg() != 2 {
+			return fail(exitUsage, fmt.Errorf("usage: barcode-music-player mappings delete <barcode>"))
+		}
+		if _, ok := store.Get(f.Arg(1)); !ok {
+			return fail(exitNotFound, fmt.Errorf("no mapping for barcode %s", f.Arg(1)))
+		}
+		if err := store.Delete(f.Arg(1)); err != nil {
+			return err
+		}
+		fmt.Printf("🗑️  Deleted mapping for %s\n", f.Arg(1))
+		return nil
+	}
+
+	f.Usage()
+	return fail(exitUsage, fmt.Errorf("unknown mappings action %q", action))
+}
+
+func listMappings(store *mappings.Store, asJSON bool) error {
+	list := store.List()
+	if asJSON {
+		return printJSON(list)
+	}
+
+	if len(list) == 0 {
+		fmt.Printf("No mappings in %s\n", store.Path())
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BARCODE\tALBUM\tOPTIONS\tNOTE")
+	for _, m := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.Barcode, m.AlbumURI, mappingOptions(m), m.Note)
+	}
+	return w.Flush()
+}
+
+// mappingOptions summarizes the playback options a mapping overrides.
+func mappingOptions(m mappings.Mapping) string {
+	var options []string
+	if m.Shuffle != nil {
+		options = append(options, "shuffle="+strconv.FormatBool(*m.Shuffle))
+	}
+	if m.Repeat != "" {
+		options = append(options, "repeat="+m.Repeat)
+	}
+	if m.StartTrack > 0 {
+		options = append(options, "start-track="+strconv.Itoa(m.StartTrack))
+	}
+	if m.StartTrackURI != "" {
+		options = append(options, "start-track-uri="+m.StartTrackURI)
+	}
+	if m.PositionMS > 0 {
+		options = append(options, "position-ms="+strconv.Itoa(m.PositionMS))
+	}
+	if m.Volume != nil {
+		options = append(options, "volume="+strconv.Itoa(*m.Volume))
+	}
+	if m.Device != "" {
+		options = append(options, "device="+m.Device)
+	}
+	return strings.Join(options, " ")
+}
+
+// setMapping creates or updates a mapping. Options that aren't given keep
+// their current value.
+func setMapping(store *mappings.Store, args []string, asJSON bool) error {
+	fs := flag.NewFlagSet("mappings set", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: barcode-music-player mappings set [flags] <barcode>\n\nFlags:")
+		fs.PrintDefaults()
+	}
+
+	album := fs.String("album", "", "Spotify album URI or link to play")
+	note := fs.String("note", "", "note for yourself")
+	shuffle := fs.Bool("shuffle", false, "shuffle playback")
+	repeat := fs.String("repeat", "", "off, context or track")
+	startTrack := fs.Int("start-track", 0, "track to start at (1-based)")
+	startTrackURI := fs.String("start-track-uri", "", "Spotify URI of the track to start at")
+	positionMS := fs.Int("position-ms", 0, "position in the first track in milliseconds")
+	volume := fs.Int("volume", 0, "volume percentage")
+	device := fs.String("device", "", "device name or ID to play on")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fail(exitUsage, err)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fail(exitUsage, fmt.Errorf("expected a barcode"))
+	}
+
+	m, _ := store.Get(fs.Arg(0))
+	m.Barcode = fs.Arg(0)
+
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "album":
+			m.AlbumURI = *album
+		case "note":
+			m.Note = *note
+		case "shuffle":
+			m.Shuffle = shuffle
+		case "repeat":
+			m.Repeat = *repeat
+		case "start-track":
+			m.StartTrack = *startTrack
+		case "start-track-uri":
+			m.StartTrackURI = *startTrackURI
+		case "position-ms":
+			m.PositionMS = *positionMS
+		case "volume":
+			m.Volume = volume
+		case "device":
+			m.Device = *device
+		}
+	})
+
+	if err := m.Validate(); err != nil {
+		return fail(exitUsage, err)
+	}
+
+	if err := store.Set(m); err != nil {
+		return err
+	}
+
+	if asJSON {
+		return printJSON(m)
+	}
+	fmt.Printf("📌 Saved mapping for %s\n", m.Barcode)
+	return nil
+}
+
+func runHistory(args []string) error {
+	f := newFlags("history", "")
+
+	var filter history.Filter
+	since := f.String("since", "", "only scans since a date, timestamp or duration like 7d")
+	until := f.String("until", "", "only scans before a date, timestamp or duration like 7d")
+	f.StringVar(&filter.Barcode, "barcode", "", "only scans of this barcode")
+	f.StringVar(&filter.Source, "source", "", "only scans from this input, e.g. evdev or api")
+	f.StringVar(&filter.Outcome, "outcome", "", "only scans with this outcome, e.g. played or not-found")
+	f.StringVar(&filter.Search, "search", "", "only scans whose barcode, album, artist or error contains this text")
+	f.BoolVar(&filter.Failed, "failed", false, "only scans that failed")
+	f.IntVar(&filter.Limit, "limit", 20, "show the most recent scans only, 0 for all")
+	asCSV := f.Bool("csv", false, "export as CSV")
+
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+
+	// --profile selects the profile's settings, and its scans
+	filter.Profile = f.profile
+
+	var err error
+	filter.Since, filter.Until, err = stats.ParsePeriod(*since, *until, "", time.Now())
+	if err != nil {
+		return fail(exitUsage, err)
+	}
+
+	if err := f.loadConfig(); err != nil {
+		return err
+	}
+
+	log := history.Open(cfg.HistoryFile)
+	entries, err := log.Read(filter)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *asCSV:
+		return history.WriteCSV(os.Stdout, entries)
+	case f.json:
+		if entries == nil {
+			entries = []history.Entry{}
+		}
+		return printJSON(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("No scans in %s\n", log.Path())
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tBARCODE\tSOURCE\tOUTCOME\tALBUM")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Time.Local().Format("2006-01-02 15:04"), e.Barcode, e.Source, e.Outcome, describeEntry(e))
+	}
+	return w.Flush()
+}
+
+// describeEntry summarizes what a scan was, or why it failed.
+func describeEntry(e history.Entry) string {
+	var description string
+	switch {
+	case e.Command != "":
+		description = "command: " + e.Command
+	case e.Album != "":
+		description = fmt.Sprintf("%s · %s", e.Album, e.Artist)
+	}
+
+	if e.Failed() && e.Error != "" {
+		// Only the first line of multi-line errors
+		message, _, _ := strings.Cut(e.Error, "\n")
+		if description == "" {
+			return message
+		}
+		description += " (" + message + ")"
+	}
+	return description
+}
+
+func runStats(args []string) error {
+	f := newFlags("stats", "")
+	since := f.String("since", "", "only scans since a date, timestamp or duration like 30d")
+	until := f.String("until", "", "only scans before a date, timestamp or duration like 30d")
+	month := f.String("month", "", "only scans of a month, as YYYY-MM")
+	top := f.Int("top", 10, "how many albums and artists to list")
+	staleDays := f.Int("stale-days", 180, "list albums not played for this many days")
+	report := f.String("report", "", "render a report: markdown or html")
+
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+
+	start, end, err := stats.ParsePeriod(*since, *until, *month, time.Now())
+	if err != nil {
+		return fail(exitUsage, err)
+	}
+
+	if err := f.loadConfig(); err != nil {
+		return err
+	}
+
+	entries, err := history.Open(cfg.HistoryFile).Read(history.Filter{Profile: f.profile})
+	if err != nil {
+		return err
+	}
+
+	var owned []collection.Item
+	if cfg.CollectionEnabled {
+		store, err := collection.Open(cfg.CollectionFile)
+		if err != nil {
+			return err
+		}
+		owned = store.List()
+	}
+
+	staleAfter := time.Duration(*staleDays) * 24 * time.Hour
+	s := stats.Compute(entries, stats.Options{
+		Since:      start,
+		Until:      end,
+		Top:        *top,
+		StaleAfter: staleAfter,
+		Collection: owned,
+	})
+
+	switch {
+	case *report != "":
+		if err := stats.WriteReport(os.Stdout, s, *report, stats.Title(start, end), staleAfter); err != nil {
+			return fail(exitUsage, err)
+		}
+		return nil
+	case f.json:
+		return printJSON(s)
+	}
+
+	printStats(s, *staleDays)
+	return nil
+}
+
+func printStats(s *stats.Stats, staleDays int) {
+	fmt.Printf("📊 %d scans, %d different albums, %d failed (%.0f%%), %d commands, %d cataloged\n", s.Scans, s.AlbumCount, s.Failed, s.FailureRate*100, s.Commands, s.Cataloged)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	section := func(title string) {
+		fmt.Fprintf(w, "\n%s\n", title)
+	}
+
+	if len(s.Albums) > 0 {
+		section("💿 Most played albums")
+		for _, c := range s.Albums {
+			fmt.Fprintf(w, "   %d\t%s · %s\n", c.Count, c.Name, c.Artist)
+		}
+	}
+	if len(s.Artists) > 0 {
+		section("🎤 Most played artists")
+		for _, c := range s.Artists {
+			fmt.Fprintf(w, "   %d\t%s\n", c.Count, c.Name)
+		}
+	}
+	if len(s.Weeks) > 0 {
+		section("📅 Scans per week")
+		for _, p := range s.Weeks {
+			fmt.Fprintf(w, "   %s\t%d scans\t%d failed\n", p.Period, p.Scans, p.Failed)
+		}
+	}
+	if len(s.Failures) > 0 {
+		section("❌ Failures by cause")
+		for _, c := range s.Failures {
+			fmt.Fprintf(w, "   %d\t%s\n", c.Count, c.Name)
+		}
+	}
+	if len(s.Unplayed) > 0 {
+		section(fmt.Sprintf("💤 Not played in %d days", staleDays))
+		for _, album := range s.Unplayed {
+			lastPlayed := "never"
+			if album.Plays > 0 {
+				lastPlayed = album.LastPlayed.Local().Format("2006-01-02")
+			}
+			fmt.Fprintf(w, "   %s\t%s · %s\n", lastPlayed, album.Name, album.Artist)
+		}
+	}
+	w.Flush()
+}
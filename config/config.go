@@ -3,43 +3,435 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
+
+	"barcode-music-player/spotify"
+)
+
+// Rescan policies decide what happens when the scanned album is already the
+// current playback context.
+const (
+	RescanIgnore      = "ignore"
+	RescanRestart     = "restart"
+	RescanTogglePause = "toggle-pause"
+	RescanNext        = "next"
+)
+
+// Play modes decide what happens with a newly scanned album.
+const (
+	ModePlay  = "play"
+	ModeQueue = "queue"
+	// ModeCatalog adds scanned releases to the collection without playing
+	ModeCatalog = "catalog"
+)
+
+// Commands that can be triggered by scanning a command barcode.
+const (
+	CommandToggleMode = "toggle-mode"
+	CommandShowQueue  = "show-queue"
+	CommandClearQueue = "clear-queue"
+	CommandReadCD     = "read-cd"
+	CommandPause      = "pause"
+	CommandResume     = "resume"
+	CommandNext       = "next"
+	CommandPrevious   = "previous"
+	// CommandReplay plays the last REPLAY_COUNT albums from the history again
+	CommandReplay = "replay"
+	// CommandToggleCatalog switches catalog mode on and off
+	CommandToggleCatalog = "toggle-catalog"
 )
 
+// Commands lists every command that can be mapped to a barcode or triggered
+// through the API.
+var Commands = []string{
+	CommandToggleMode, CommandShowQueue, CommandClearQueue, CommandReadCD,
+	CommandPause, CommandResume, CommandNext, CommandPrevious, CommandReplay,
+	CommandToggleCatalog,
+}
+
 type Config struct {
 	SpotifyClientID     string
 	SpotifyClientSecret string
 	SpotifyRedirectURI  string
-	MusicBrainzURL      string
+	// SpotifyMarket is an ISO 3166-1 country code; empty means the account's country
+	SpotifyMarket  string
+	MusicBrainzURL string
+
+	// Profile selects profile-specific settings such as EDITION_PREFERENCE_<PROFILE>
+	Profile string
+
+	// EditionPreference is a comma-separated list of edition preferences
+	// used to pick between duplicate Spotify albums
+	EditionPreference string
+
+	// Scan handling
+	ScanDebounce time.Duration
+	RescanPolicy string
+	PlayMode     string
+
+	// CDDevice is the drive read by the read-cd command
+	CDDevice string
+
+	// CommandBarcodes maps barcodes to the command they trigger
+	CommandBarcodes map[string]string
+
+	// Default playback options, overridable per barcode in the mappings file
+	PlayShuffle bool
+	PlayRepeat  string
+	// PlayVolume is nil to leave the volume unchanged
+	PlayVolume *int
+	PlayDevice string
+
+	// MappingsFile stores per-barcode albums and playback options
+	MappingsFile string
+
+	// HistoryFile is the JSON Lines log of scans, unless HistoryEnabled is off
+	HistoryEnabled bool
+	HistoryFile    string
+	// ReplayCount is how many albums the replay command plays again
+	ReplayCount int
+
+	// CollectionFile records the scanned releases, unless CollectionEnabled
+	// is off
+	CollectionEnabled bool
+	CollectionFile    string
+
+	// Sync saves played albums to the Spotify library and adds them to a
+	// playlist of the whole collection and a rolling playlist of the last
+	// SyncRecentSize albums
+	SyncLibrary         bool
+	SyncPlaylistEnabled bool
+	SyncPlaylistName    string
+	SyncRecentEnabled   bool
+	SyncRecentName      string
+	SyncRecentSize      int
+
+	// DryRun resolves scans and reports what would be played without
+	// changing playback
+	DryRun bool
+
+	// Input sources
+	InputStdinEnabled  bool
+	InputEvdevEnabled  bool
+	InputEvdevDevice   string
+	InputSerialEnabled bool
+	InputSerialDevice  string
+	InputHTTPEnabled   bool
+	InputHTTPAddr      string
+	InputWatchEnabled  bool
+	InputWatchDir      string
+
+	// HTTP control API
+	APIEnabled bool
+	APIAddr    string
+	APIToken   string
+
+	// ShutdownTimeout is how long the serve mode waits for a scan in progress
+	// when stopping
+	ShutdownTimeout time.Duration
+}
+
+// processEnv records which variables were set before the .env file was
+// loaded, so a reload doesn't override them.
+var (
+	processEnvOnce sync.Once
+	processEnv     map[string]bool
+)
+
+func recordProcessEnv() {
+	processEnvOnce.Do(func() {
+		processEnv = make(map[string]bool)
+		for _, entry := range os.Environ() {
+			key, _, _ := strings.Cut(entry, "=")
+			processEnv[key] = true
+		}
+	})
 }
 
 func Load() (*Config, error) {
+	recordProcessEnv()
+
 	// Load .env file if it exists (ignore error if file doesn't exist)
 	_ = godotenv.Load()
 
+	env := &envParser{}
 	config := &Config{
 		SpotifyClientID:     os.Getenv("SPOTIFY_CLIENT_ID"),
 		SpotifyClientSecret: os.Getenv("SPOTIFY_CLIENT_SECRET"),
 		SpotifyRedirectURI:  getEnvOrDefault("SPOTIFY_REDIRECT_URI", "http://127.0.0.1:8080/callback"),
+		SpotifyMarket:       strings.ToUpper(os.Getenv("SPOTIFY_MARKET")),
 		MusicBrainzURL:      getEnvOrDefault("MUSICBRAINZ_URL", "https://musicbrainz.org/ws/2"),
+
+		Profile: getEnvOrDefault("PROFILE", "default"),
+
+		ScanDebounce: env.getDuration("SCAN_DEBOUNCE", 1*time.Second),
+		RescanPolicy: getEnvOrDefault("RESCAN_POLICY", RescanRestart),
+		PlayMode:     getEnvOrDefault("PLAY_MODE", ModePlay),
+		CDDevice:     getEnvOrDefault("CD_DEVICE", "/dev/cdrom"),
+
+		PlayShuffle:  env.getBool("PLAY_SHUFFLE", false),
+		PlayRepeat:   os.Getenv("PLAY_REPEAT"),
+		PlayVolume:   env.getOptionalInt("PLAY_VOLUME"),
+		PlayDevice:   os.Getenv("PLAY_DEVICE"),
+		MappingsFile: os.Getenv("MAPPINGS_FILE"),
+		DryRun:       env.getBool("DRY_RUN", false),
+
+		HistoryEnabled: env.getBool("HISTORY_ENABLED", true),
+		HistoryFile:    os.Getenv("HISTORY_FILE"),
+		ReplayCount:    env.getInt("REPLAY_COUNT", 1),
+
+		CollectionEnabled: env.getBool("COLLECTION_ENABLED", true),
+		CollectionFile:    os.Getenv("COLLECTION_FILE"),
+
+		SyncLibrary:         env.getBool("SYNC_LIBRARY", false),
+		SyncPlaylistEnabled: env.getBool("SYNC_PLAYLIST_ENABLED", false),
+		SyncPlaylistName:    getEnvOrDefault("SYNC_PLAYLIST_NAME", "Physical Collection"),
+		SyncRecentEnabled:   env.getBool("SYNC_RECENT_ENABLED", false),
+		SyncRecentName:      getEnvOrDefault("SYNC_RECENT_NAME", "Recently scanned"),
+		SyncRecentSize:      env.getInt("SYNC_RECENT_SIZE", 20),
+
+		InputStdinEnabled:  env.getBool("INPUT_STDIN_ENABLED", true),
+		InputEvdevEnabled:  env.getBool("INPUT_EVDEV_ENABLED", false),
+		InputEvdevDevice:   os.Getenv("INPUT_EVDEV_DEVICE"),
+		InputSerialEnabled: env.getBool("INPUT_SERIAL_ENABLED", false),
+		InputSerialDevice:  os.Getenv("INPUT_SERIAL_DEVICE"),
+		InputHTTPEnabled:   env.getBool("INPUT_HTTP_ENABLED", false),
+		InputHTTPAddr:      getEnvOrDefault("INPUT_HTTP_ADDR", "127.0.0.1:8081"),
+		InputWatchEnabled:  env.getBool("INPUT_WATCH_ENABLED", false),
+		InputWatchDir:      os.Getenv("INPUT_WATCH_DIR"),
+
+		APIEnabled: env.getBool("API_ENABLED", false),
+		APIAddr:    getEnvOrDefault("API_ADDR", "127.0.0.1:8082"),
+		APIToken:   os.Getenv("API_TOKEN"),
+
+		ShutdownTimeout: env.getDuration("SHUTDOWN_TIMEOUT", 10*time.Second),
 	}
 
-	// Validate required configuration
-	if config.SpotifyClientID == "" {
-		return nil, fmt.Errorf("SPOTIFY_CLIENT_ID environment variable is required")
+	if env.err != nil {
+		return nil, env.err
 	}
 
-	if config.SpotifyClientSecret == "" {
-		return nil, fmt.Errorf("SPOTIFY_CLIENT_SECRET environment variable is required")
+	config.EditionPreference = getProfileEnv(config.Profile, "EDITION_PREFERENCE", "standard,match-tracks")
+
+	commands, err := parseCommandBarcodes(os.Getenv("COMMAND_BARCODES"))
+	if err != nil {
+		return nil, err
+	}
+	config.CommandBarcodes = commands
+
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
 	return config, nil
 }
 
+// Validate checks the configuration. Load already does, but settings changed
+// afterwards (by command line flags, for example) need checking again.
+func (c *Config) Validate() error {
+	// Validate required configuration
+	if c.SpotifyClientID == "" {
+		return fmt.Errorf("SPOTIFY_CLIENT_ID environment variable is required")
+	}
+
+	if c.SpotifyClientSecret == "" {
+		return fmt.Errorf("SPOTIFY_CLIENT_SECRET environment variable is required")
+	}
+
+	switch c.RescanPolicy {
+	case RescanIgnore, RescanRestart, RescanTogglePause, RescanNext:
+	default:
+		return fmt.Errorf("RESCAN_POLICY must be one of %s, %s, %s or %s", RescanIgnore, RescanRestart, RescanTogglePause, RescanNext)
+	}
+
+	switch c.PlayMode {
+	case ModePlay, ModeQueue, ModeCatalog:
+	default:
+		return fmt.Errorf("PLAY_MODE must be one of %s, %s or %s", ModePlay, ModeQueue, ModeCatalog)
+	}
+
+	switch c.PlayRepeat {
+	case "", spotify.RepeatOff, spotify.RepeatContext, spotify.RepeatTrack:
+	default:
+		return fmt.Errorf("PLAY_REPEAT must be one of %s, %s or %s", spotify.RepeatOff, spotify.RepeatContext, spotify.RepeatTrack)
+	}
+
+	if c.PlayVolume != nil && (*c.PlayVolume < 0 || *c.PlayVolume > 100) {
+		return fmt.Errorf("PLAY_VOLUME must be between 0 and 100")
+	}
+
+	if c.PlayMode == ModeCatalog && !c.CollectionEnabled {
+		return fmt.Errorf("PLAY_MODE=%s needs COLLECTION_ENABLED", ModeCatalog)
+	}
+
+	if c.ReplayCount < 1 {
+		return fmt.Errorf("REPLAY_COUNT must be at least 1")
+	}
+
+	if c.SyncPlaylistEnabled && strings.TrimSpace(c.SyncPlaylistName) == "" {
+		return fmt.Errorf("SYNC_PLAYLIST_NAME is required when SYNC_PLAYLIST_ENABLED is set")
+	}
+
+	if c.SyncRecentEnabled && strings.TrimSpace(c.SyncRecentName) == "" {
+		return fmt.Errorf("SYNC_RECENT_NAME is required when SYNC_RECENT_ENABLED is set")
+	}
+
+	if c.SyncRecentSize < 1 {
+		return fmt.Errorf("SYNC_RECENT_SIZE must be at least 1")
+	}
+
+	if c.InputEvdevEnabled && c.InputEvdevDevice == "" {
+		return fmt.Errorf("INPUT_EVDEV_DEVICE is required when INPUT_EVDEV_ENABLED is set")
+	}
+
+	if c.InputSerialEnabled && c.InputSerialDevice == "" {
+		return fmt.Errorf("INPUT_SERIAL_DEVICE is required when INPUT_SERIAL_ENABLED is set")
+	}
+
+	if c.InputWatchEnabled && c.InputWatchDir == "" {
+		return fmt.Errorf("INPUT_WATCH_DIR is required when INPUT_WATCH_ENABLED is set")
+	}
+
+	if c.APIEnabled && c.APIToken == "" {
+		return fmt.Errorf("API_TOKEN is required when API_ENABLED is set")
+	}
+
+	return nil
+}
+
+// Reload loads the configuration again, picking up changes to the .env file.
+// Variables set in the process environment still take precedence.
+func Reload() (*Config, error) {
+	recordProcessEnv()
+
+	values, err := godotenv.Read()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read .env: %w", err)
+	}
+
+	for key, value := range values {
+		if !processEnv[key] {
+			os.Setenv(key, value)
+		}
+	}
+
+	return Load()
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+// getProfileEnv returns KEY_<PROFILE> if set, falling back to KEY and then to
+// defaultValue.
+func getProfileEnv(profile, key, defaultValue string) string {
+	if value := os.Getenv(key + "_" + strings.ToUpper(profile)); value != "" {
+		return value
+	}
+	return getEnvOrDefault(key, defaultValue)
+}
+
+// envParser reads typed variables, remembering the first value that doesn't
+// parse so Load can report it instead of quietly using the default.
+type envParser struct {
+	err error
+}
+
+func (e *envParser) fail(key, value, expected string) {
+	if e.err == nil {
+		e.err = fmt.Errorf("%s=%q is not %s", key, value, expected)
+	}
+}
+
+func (e *envParser) getBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		e.fail(key, value, "true or false")
+		return defaultValue
+	}
+	return parsed
+}
+
+func (e *envParser) getInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		e.fail(key, value, "a whole number")
+		return defaultValue
+	}
+	return parsed
+}
+
+// getOptionalInt returns nil if the variable is unset, so that 0 can be told
+// apart from no value.
+func (e *envParser) getOptionalInt(key string) *int {
+	if os.Getenv(key) == "" {
+		return nil
+	}
+	value := e.getInt(key, 0)
+	return &value
+}
+
+func (e *envParser) getDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		e.fail(key, value, "a duration with a unit, such as 500ms or 2s")
+		return defaultValue
+	}
+	return parsed
+}
+
+// parseCommandBarcodes parses a comma-separated list of barcode=command pairs.
+func parseCommandBarcodes(value string) (map[string]string, error) {
+	commands := make(map[string]string)
+	if value == "" {
+		return commands, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		barcode, command, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || barcode == "" {
+			return nil, fmt.Errorf("invalid COMMAND_BARCODES entry %q, expected barcode=command", pair)
+		}
+
+		if !IsCommand(command) {
+			return nil, fmt.Errorf("unknown command %q in COMMAND_BARCODES", command)
+		}
+
+		commands[barcode] = command
+	}
+
+	return commands, nil
+}
+
+// IsCommand reports whether name is one of the known commands.
+func IsCommand(name string) bool {
+	for _, command := range Commands {
+		if command == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package discid
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	framesPerSecond = 75
+	samplesPerFrame = 588
+)
+
+// ParseCdrdao builds a TOC from a cdrdao TOC file. Track positions are derived
+// from the lengths of the FILE, SILENCE and PREGAP statements, so every audio
+// file statement needs an explicit length.
+func ParseCdrdao(r io.Reader) (*TOC, error) {
+	var (
+		offsets  []int
+		position int
+		inTrack  bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" {
+			continue
+		}
+
+		fields := splitFields(line)
+		switch strings.ToUpper(fields[0]) {
+		case "TRACK":
+			offsets = append(offsets, position)
+			inTrack = true
+		case "FILE", "AUDIOFILE":
+			if !inTrack {
+				continue
+			}
+			// FILE "name" start length
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("FILE statement without a length: %s", line)
+			}
+			length, err := parseLength(fields[3])
+			if err != nil {
+				return nil, err
+			}
+			position += length
+		case "DATAFILE":
+			// DATAFILE "name" length
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("DATAFILE statement without a length: %s", line)
+			}
+			length, err := parseLength(fields[2])
+			if err != nil {
+				return nil, err
+			}
+			position += length
+		case "SILENCE", "ZERO":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("%s statement without a length", fields[0])
+			}
+			length, err := parseLength(fields[len(fields)-1])
+			if err != nil {
+				return nil, err
+			}
+			position += length
+		case "PREGAP":
+			// A pregap is silence inserted before the track's index 1
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("PREGAP statement without a length")
+			}
+			if len(offsets) == 0 {
+				return nil, fmt.Errorf("PREGAP before first TRACK")
+			}
+			length, err := parseLength(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			position += length
+			offsets[len(offsets)-1] += length
+		case "START":
+			// The track's index 1 starts this far into its data
+			if len(fields) > 1 {
+				if len(offsets) == 0 {
+					return nil, fmt.Errorf("START before first TRACK")
+				}
+				length, err := parseLength(fields[1])
+				if err != nil {
+					return nil, err
+				}
+				offsets[len(offsets)-1] += length
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read TOC file: %w", err)
+	}
+
+	if len(offsets) == 0 {
+		return nil, fmt.Errorf("no tracks found in TOC file")
+	}
+
+	toc := &TOC{
+		FirstTrack: 1,
+		LastTrack:  len(offsets),
+		LeadOut:    position + pregapSectors,
+	}
+	for _, offset := range offsets {
+		toc.Offsets = append(toc.Offsets, offset+pregapSectors)
+	}
+
+	if err := toc.validate(); err != nil {
+		return nil, err
+	}
+
+	return toc, nil
+}
+
+// splitFields splits a TOC file line on whitespace, keeping quoted file names
+// together.
+func splitFields(line string) []string {
+	var (
+		fields []string
+		field  strings.Builder
+		quoted bool
+	)
+
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case (r == ' ' || r == '\t') && !quoted:
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+		default:
+			field.WriteRune(r)
+		}
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+
+	return fields
+}
+
+// parseLength converts a cdrdao length, either MM:SS:FF or a sample count,
+// to sectors.
+func parseLength(value string) (int, error) {
+	if !strings.Contains(value, ":") {
+		samples, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid length %q", value)
+		}
+		return samples / samplesPerFrame, nil
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid MSF length %q", value)
+	}
+
+	var msf [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, fmt.Errorf("invalid MSF length %q", value)
+		}
+		msf[i] = n
+	}
+
+	return (msf[0]*60+msf[1])*framesPerSecond + msf[2], nil
+}
@@ -0,0 +1,87 @@
+package discid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCdrdao(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{
+			name: "single track",
+			file: `CD_DA
+TRACK AUDIO
+FILE "01.wav" 0 03:00:00`,
+			want: "1 1 13650 150",
+		},
+		{
+			name: "pregap, start and sample lengths",
+			file: `CD_DA
+
+// Track 1
+TRACK AUDIO
+COPY
+FILE "disc one.wav" 0 7938000
+
+TRACK AUDIO
+PREGAP 00:02:00
+FILE "disc one.wav" 7938000 04:00:00 // 18000 sectors
+
+TRACK AUDIO
+FILE "disc one.wav" 18522000 02:00:00
+START 00:01:00`,
+			want: "1 3 40800 150 13800 31875",
+		},
+		{
+			name: "silence",
+			file: `CD_DA
+TRACK AUDIO
+SILENCE 00:01:00
+FILE "01.wav" 0 01:00:00
+TRACK AUDIO
+ZERO 00:00:30
+FILE "02.wav" 0 01:00:00`,
+			want: "1 2 9255 150 4725",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toc, err := ParseCdrdao(strings.NewReader(tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := toc.String(); got != tt.want {
+				t.Errorf("got TOC %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCdrdaoInvalid(t *testing.T) {
+	tests := map[string]string{
+		"empty":                   "",
+		"no tracks":               "CD_DA\n",
+		"file without length":     "TRACK AUDIO\nFILE \"01.wav\" 0\n",
+		"datafile without length": "TRACK MODE1\nDATAFILE \"data.bin\"\n",
+		"invalid length":          "TRACK AUDIO\nFILE \"01.wav\" 0 three\n",
+		"invalid MSF":             "TRACK AUDIO\nFILE \"01.wav\" 0 03:00\n",
+		"bare pregap":             "TRACK AUDIO\nPREGAP\nFILE \"01.wav\" 0 03:00:00\n",
+		"pregap before track":     "PREGAP 00:02:00\nTRACK AUDIO\nFILE \"01.wav\" 0 03:00:00\n",
+		"start before track":      "START 00:02:00\nTRACK AUDIO\nFILE \"01.wav\" 0 03:00:00\n",
+		"bare silence":            "TRACK AUDIO\nSILENCE\n",
+		"empty track":             "TRACK AUDIO\nTRACK AUDIO\nFILE \"02.wav\" 0 03:00:00\n",
+	}
+
+	for name, file := range tests {
+		t.Run(name, func(t *testing.T) {
+			if toc, err := ParseCdrdao(strings.NewReader(file)); err == nil {
+				t.Errorf("got TOC %q, want an error", toc)
+			}
+		})
+	}
+}
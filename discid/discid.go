@@ -0,0 +1,116 @@
+package discid
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// pregapSectors is the two-second lead-in before the first track
+	pregapSectors = 150
+	maxTracks     = 99
+)
+
+// discIDEncoding is base64 with the URL-unsafe characters replaced the way
+// MusicBrainz does it.
+var discIDEncoding = base64.NewEncoding("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789._").WithPadding('-')
+
+// TOC is the table of contents of an audio CD. Offsets are absolute sector
+// addresses, including the 150 sector pregap.
+type TOC struct {
+	FirstTrack int
+	LastTrack  int
+	LeadOut    int
+	Offsets    []int
+}
+
+// ParseTOC parses a TOC in MusicBrainz's "first last leadout offset..." form,
+// as used by the toc parameter of the /discid endpoint.
+func ParseTOC(s string) (*TOC, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("TOC must have at least first track, last track, lead-out and one offset")
+	}
+
+	values := make([]int, len(fields))
+	for i, field := range fields {
+		value, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TOC value %q", field)
+		}
+		values[i] = value
+	}
+
+	toc := &TOC{
+		FirstTrack: values[0],
+		LastTrack:  values[1],
+		LeadOut:    values[2],
+		Offsets:    values[3:],
+	}
+
+	if err := toc.validate(); err != nil {
+		return nil, err
+	}
+
+	return toc, nil
+}
+
+func (t *TOC) validate() error {
+	if t.FirstTrack < 1 || t.LastTrack > maxTracks || t.FirstTrack > t.LastTrack {
+		return fmt.Errorf("invalid track range %d-%d", t.FirstTrack, t.LastTrack)
+	}
+
+	if len(t.Offsets) != t.LastTrack-t.FirstTrack+1 {
+		return fmt.Errorf("expected %d track offsets, got %d", t.LastTrack-t.FirstTrack+1, len(t.Offsets))
+	}
+
+	previous := -1
+	for _, offset := range t.Offsets {
+		if offset <= previous {
+			return fmt.Errorf("track offsets must be increasing")
+		}
+		previous = offset
+	}
+
+	if t.LeadOut <= previous {
+		return fmt.Errorf("lead-out must come after the last track")
+	}
+
+	return nil
+}
+
+// String returns the TOC in the form accepted by ParseTOC.
+func (t *TOC) String() string {
+	parts := []string{
+		strconv.Itoa(t.FirstTrack),
+		strconv.Itoa(t.LastTrack),
+		strconv.Itoa(t.LeadOut),
+	}
+	for _, offset := range t.Offsets {
+		parts = append(parts, strconv.Itoa(offset))
+	}
+	return strings.Join(parts, " ")
+}
+
+// DiscID computes the MusicBrainz Disc ID of the TOC.
+func (t *TOC) DiscID() string {
+	hash := sha1.New()
+
+	fmt.Fprintf(hash, "%02X", t.FirstTrack)
+	fmt.Fprintf(hash, "%02X", t.LastTrack)
+
+	// The lead-out takes the place of track 0, unused tracks are zero
+	offsets := make([]int, maxTracks+1)
+	offsets[0] = t.LeadOut
+	for i, offset := range t.Offsets {
+		offsets[t.FirstTrack+i] = offset
+	}
+	for _, offset := range offsets {
+		fmt.Fprintf(hash, "%08X", offset)
+	}
+
+	return discIDEncoding.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,56 @@
+package discid
+
+import "testing"
+
+func TestDiscID(t *testing.T) {
+	tests := []struct {
+		toc  string
+		want string
+	}{
+		// From libdiscid's tests
+		{
+			"1 22 303602 150 9700 25887 39297 53795 63735 77517 94877 107270 123552 135522 148422 161197 174790 192022 205545 218010 228700 239590 255470 266932 288750",
+			"xUp1F2NkfP8s8jaeFn_Av3jNEI4-",
+		},
+		// From the MusicBrainz web service documentation
+		{
+			"1 12 267257 150 22767 41887 58317 72102 91375 104652 115380 132165 143932 159870 174597",
+			"I5l9cCSFccLKFEKS.7wqSZAorPU-",
+		},
+	}
+
+	for _, tt := range tests {
+		toc, err := ParseTOC(tt.toc)
+		if err != nil {
+			t.Fatalf("ParseTOC(%q): %v", tt.toc, err)
+		}
+		if got := toc.DiscID(); got != tt.want {
+			t.Errorf("DiscID(%q) = %s, want %s", tt.toc, got, tt.want)
+		}
+		if got := toc.String(); got != tt.toc {
+			t.Errorf("String() = %q, want %q", got, tt.toc)
+		}
+	}
+}
+
+func TestParseTOCInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"1 1 1000",
+		"1 x 1000 150",
+		"0 1 1000 150",
+		"2 1 1000 150",
+		"1 100 1000 150",
+		"1 2 1000 150",
+		"1 1 1000 150 300",
+		"1 2 1000 300 150",
+		"1 2 1000 150 150",
+		"1 1 150 150",
+	}
+
+	for _, s := range tests {
+		if toc, err := ParseTOC(s); err == nil {
+			t.Errorf("ParseTOC(%q) = %v, want an error", s, toc)
+		}
+	}
+}
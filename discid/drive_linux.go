@@ -0,0 +1,99 @@
+//go:build linux
+
+package discid
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	cdromReadTOCHeader = 0x5305
+	cdromReadTOCEntry  = 0x5306
+	cdromLBA           = 0x01
+	cdromLeadOut       = 0xAA
+	cdromDataTrack     = 0x04
+
+	// Enhanced CDs put a data session after the audio; MusicBrainz ends the
+	// audio session 11400 sectors before it.
+	dataSessionGap = 11400
+)
+
+type tocHeader struct {
+	FirstTrack uint8
+	LastTrack  uint8
+}
+
+type tocEntry struct {
+	Track    uint8
+	AdrCtrl  uint8
+	Format   uint8
+	_        uint8
+	LBA      int32
+	DataMode uint8
+	_        [3]uint8
+}
+
+// ReadDrive reads the TOC of the disc in a CD drive such as /dev/cdrom.
+func ReadDrive(device string) (*TOC, error) {
+	file, err := os.OpenFile(device, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CD drive %s: %w", device, err)
+	}
+	defer file.Close()
+
+	var header tocHeader
+	if err := ioctl(file, cdromReadTOCHeader, unsafe.Pointer(&header)); err != nil {
+		return nil, fmt.Errorf("failed to read TOC header (is there an audio CD in the drive?): %w", err)
+	}
+
+	toc := &TOC{
+		FirstTrack: int(header.FirstTrack),
+		LastTrack:  int(header.LastTrack),
+	}
+
+	var lastIsData bool
+	for track := toc.FirstTrack; track <= toc.LastTrack; track++ {
+		entry, err := readEntry(file, uint8(track))
+		if err != nil {
+			return nil, err
+		}
+		toc.Offsets = append(toc.Offsets, int(entry.LBA)+pregapSectors)
+		lastIsData = (entry.AdrCtrl>>4)&cdromDataTrack != 0
+	}
+
+	leadOut, err := readEntry(file, cdromLeadOut)
+	if err != nil {
+		return nil, err
+	}
+	toc.LeadOut = int(leadOut.LBA) + pregapSectors
+
+	if lastIsData && toc.LastTrack > toc.FirstTrack {
+		toc.LeadOut = toc.Offsets[len(toc.Offsets)-1] - dataSessionGap
+		toc.Offsets = toc.Offsets[:len(toc.Offsets)-1]
+		toc.LastTrack--
+	}
+
+	if err := toc.validate(); err != nil {
+		return nil, err
+	}
+
+	return toc, nil
+}
+
+func readEntry(file *os.File, track uint8) (*tocEntry, error) {
+	entry := tocEntry{Track: track, Format: cdromLBA}
+	if err := ioctl(file, cdromReadTOCEntry, unsafe.Pointer(&entry)); err != nil {
+		return nil, fmt.Errorf("failed to read TOC entry for track %d: %w", track, err)
+	}
+	return &entry, nil
+}
+
+func ioctl(file *os.File, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
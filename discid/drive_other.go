@@ -0,0 +1,10 @@
+//go:build !linux
+
+package discid
+
+import "fmt"
+
+// ReadDrive reads the TOC of the disc in a CD drive such as /dev/cdrom.
+func ReadDrive(device string) (*TOC, error) {
+	return nil, fmt.Errorf("reading a CD drive is only supported on Linux, pass a TOC instead")
+}
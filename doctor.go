@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"barcode-music-player/config"
+	"barcode-music-player/mappings"
+	"barcode-music-player/match"
+	"barcode-music-player/musicbrainz"
+	"barcode-music-player/player"
+)
+
+// Check results of the doctor command.
+const (
+	checkOK      = "ok"
+	checkWarning = "warning"
+	checkFailed  = "failed"
+)
+
+type check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// doctorBarcode is looked up to check that MusicBrainz can be reached.
+const doctorBarcode = "5099902988023"
+
+// runDoctor checks the configuration, the Spotify login, the devices and the
+// input sources, and exits with exitFailure if any check failed.
+func runDoctor(args []string) error {
+	f := newFlags("doctor", "")
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+
+	var checks []check
+	add := func(name, status, format string, a ...interface{}) {
+		checks = append(checks, check{Name: name, Status: status, Detail: fmt.Sprintf(format, a...)})
+	}
+
+	if err := f.loadConfig(); err != nil {
+		add("configuration", checkFailed, "%v", err)
+		return reportChecks(checks, f.json)
+	}
+	add("configuration", checkOK, "profile %s", cfg.Profile)
+
+	if _, err := match.ParsePreference(cfg.EditionPreference); err != nil {
+		add("edition preference", checkFailed, "%v", err)
+	} else {
+		add("edition preference", checkOK, "%s", cfg.EditionPreference)
+	}
+
+	if store, err := mappings.Open(cfg.MappingsFile); err != nil {
+		add("mappings", checkFailed, "%v", err)
+	} else {
+		add("mappings", checkOK, "%d in %s", len(store.List()), store.Path())
+	}
+
+	checks = append(checks, checkSpotify(f.progress())...)
+
+	musicbrainzClient = musicbrainz.NewClient(cfg.MusicBrainzURL)
+	if _, err := musicbrainzClient.SearchByBarcode(context.Background(), doctorBarcode); err != nil && !errors.Is(err, musicbrainz.ErrNotFound) {
+		add("musicbrainz", checkFailed, "%v", err)
+	} else {
+		add("musicbrainz", checkOK, "%s", cfg.MusicBrainzURL)
+	}
+
+	checks = append(checks, checkInputs()...)
+
+	return reportChecks(checks, f.json)
+}
+
+func checkSpotify(out io.Writer) []check {
+	if err := connect(out); err != nil {
+		return []check{{"spotify login", checkFailed, err.Error()}}
+	}
+
+	user, err := spotifyClient.GetCurrentUser(context.Background())
+	if err != nil {
+		return []check{{"spotify login", checkFailed, err.Error()}}
+	}
+
+	checks := []check{{"spotify login", checkOK, fmt.Sprintf("%s (%s)", user.DisplayName, user.ID)}}
+
+	if scopes := player.SyncScopes(cfg); len(scopes) > 0 {
+		if missing := spotifyClient.MissingScopes(scopes...); len(missing) > 0 {
+			checks = append(checks, check{"spotify permissions", checkFailed, fmt.Sprintf("syncing needs %s, run 'barcode-music-player login' again", strings.Join(missing, ", "))})
+		} else {
+			checks = append(checks, check{"spotify permissions", checkOK, "library and playlist sync allowed"})
+		}
+	}
+
+	if user.Product != "premium" {
+		checks = append(checks, check{"spotify plan", checkWarning, fmt.Sprintf("%s, playback control needs Spotify Premium", user.Product)})
+	} else {
+		checks = append(checks, check{"spotify plan", checkOK, user.Product})
+	}
+
+	market := cfg.SpotifyMarket
+	if market == "" {
+		market = user.Country
+	}
+	if market == "" {
+		checks = append(checks, check{"spotify market", checkWarning, "unknown, set SPOTIFY_MARKET"})
+	} else {
+		checks = append(checks, check{"spotify market", checkOK, market})
+	}
+
+	devices, err := spotifyClient.GetAvailableDevices(context.Background())
+	switch {
+	case err != nil:
+		checks = append(checks, check{"spotify devices", checkFailed, err.Error()})
+	case len(devices) == 0:
+		checks = append(checks, check{"spotify devices", checkWarning, "none found, open Spotify on the device you want to play on"})
+	default:
+		checks = append(checks, check{"spotify devices", checkOK, fmt.Sprintf("%d available", len(devices))})
+	}
+
+	if cfg.PlayDevice != "" {
+		found := false
+		for _, device := range devices {
+			if device.ID == cfg.PlayDevice || device.Name == cfg.PlayDevice {
+				found = true
+			}
+		}
+		if found {
+			checks = append(checks, check{"play device", checkOK, cfg.PlayDevice})
+		} else {
+			checks = append(checks, check{"play device", checkWarning, fmt.Sprintf("%s is not available right now", cfg.PlayDevice)})
+		}
+	}
+
+	return checks
+}
+
+func checkInputs() []check {
+	var checks []check
+
+	checkPath := func(name, path string) {
+		file, err := os.Open(path)
+		if err != nil {
+			checks = append(checks, check{name, checkFailed, err.Error()})
+			return
+		}
+		file.Close()
+		checks = append(checks, check{name, checkOK, path})
+	}
+
+	if cfg.InputStdinEnabled {
+		checks = append(checks, check{"stdin input", checkOK, "enabled"})
+	}
+	if cfg.InputEvdevEnabled {
+		checkPath("evdev input", cfg.InputEvdevDevice)
+	}
+	if cfg.InputSerialEnabled {
+		checkPath("serial input", cfg.InputSerialDevice)
+	}
+	if cfg.InputHTTPEnabled {
+		checks = append(checks, check{"http input", checkOK, cfg.InputHTTPAddr})
+	}
+	if cfg.InputWatchEnabled {
+		checkPath("folder input", cfg.InputWatchDir)
+	}
+	if cfg.APIEnabled {
+		checks = append(checks, check{"control api", checkOK, cfg.APIAddr})
+	}
+
+	for _, command := range cfg.CommandBarcodes {
+		if command == config.CommandReadCD {
+			checkPath("cd drive", cfg.CDDevice)
+			break
+		}
+	}
+
+	return checks
+}
+
+func reportChecks(checks []check, asJSON bool) error {
+	failed := 0
+	for _, c := range checks {
+		if c.Status == checkFailed {
+			failed++
+		}
+	}
+
+	if asJSON {
+		if err := printJSON(checks); err != nil {
+			return err
+		}
+	} else {
+		for _, c := range checks {
+			icon := "✅"
+			switch c.Status {
+			case checkWarning:
+				icon = "⚠️ "
+			case checkFailed:
+				icon = "❌"
+			}
+			fmt.Printf("%s %-20s %s\n", icon, c.Name, c.Detail)
+		}
+	}
+
+	if failed > 0 {
+		return fail(exitFailure, fmt.Errorf("%d check(s) failed", failed))
+	}
+	return nil
+}
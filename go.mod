@@ -2,4 +2,4 @@ module barcode-music-player
 
 go 1.24.5
 
-require github.com/joho/godotenv v1.5.1 // indirect
+require github.com/joho/godotenv v1.5.1
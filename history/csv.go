@@ -0,0 +1,34 @@
+package history
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{
+	"time", "barcode", "source", "profile", "command", "release_id",
+	"album", "artist", "album_uri", "outcome", "error", "dry_run",
+}
+
+// WriteCSV writes entries as CSV with a header row.
+func WriteCSV(w io.Writer, entries []Entry) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		record := []string{
+			e.Time.Format(time.RFC3339), e.Barcode, e.Source, e.Profile, e.Command, e.ReleaseID,
+			e.Album, e.Artist, e.AlbumURI, e.Outcome, e.Error, strconv.FormatBool(e.DryRun),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,227 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcomes of a scan. Failures are split by cause so they can be counted.
+const (
+	OutcomePlayed = "played"
+	// OutcomeCommand is a command barcode that ran
+	OutcomeCommand = "command"
+	// OutcomeDryRun is a dry-run scan that would have played
+	OutcomeDryRun = "dry-run"
+	// OutcomeQueued is an album queued after the current one in queue mode
+	OutcomeQueued = "queued"
+	// OutcomePaused, OutcomeResumed, OutcomeSkipped and OutcomeIgnored are
+	// rescans of the album already playing, handled by RESCAN_POLICY
+	OutcomePaused  = "paused"
+	OutcomeResumed = "resumed"
+	OutcomeSkipped = "skipped"
+	OutcomeIgnored = "ignored"
+	// OutcomeCataloged is a scan in catalog mode, added to the collection
+	// without playing
+	OutcomeCataloged = "cataloged"
+	// OutcomeNotFound means MusicBrainz has no release for the scan
+	OutcomeNotFound         = "not-found"
+	OutcomeNotOnSpotify     = "not-on-spotify"
+	OutcomeRegionRestricted = "region-restricted"
+	OutcomeNoDevice         = "no-device"
+	OutcomePremiumRequired  = "premium-required"
+	OutcomeFailed           = "failed"
+)
+
+// Entry is one scan in the history.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Barcode string    `json:"barcode"`
+	Source  string    `json:"source"`
+	Profile string    `json:"profile,omitempty"`
+	Command string    `json:"command,omitempty"`
+
+	// ReleaseID is the MusicBrainz release the scan was identified as
+	ReleaseID string `json:"release_id,omitempty"`
+	Album     string `json:"album,omitempty"`
+	Artist    string `json:"artist,omitempty"`
+	AlbumURI  string `json:"album_uri,omitempty"`
+
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+	DryRun  bool   `json:"dry_run,omitempty"`
+}
+
+// Failed reports whether the scan didn't play or run anything.
+func (e Entry) Failed() bool {
+	switch e.Outcome {
+	case OutcomePlayed, OutcomeQueued, OutcomePaused, OutcomeResumed, OutcomeSkipped, OutcomeIgnored,
+		OutcomeCommand, OutcomeDryRun, OutcomeCataloged:
+		return false
+	}
+	return true
+}
+
+// Played reports whether the scan started the album or queued it, as opposed
+// to pausing or skipping the album already playing.
+func (e Entry) Played() bool {
+	return e.Outcome == OutcomePlayed || e.Outcome == OutcomeQueued
+}
+
+// Log is an append-only JSON Lines file of scans.
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// DefaultPath returns the history file next to the stored Spotify token.
+func DefaultPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".barcode-music-player-history.jsonl")
+}
+
+// Open returns the log at path. The file is created by the first Append.
+func Open(path string) *Log {
+	if path == "" {
+		path = DefaultPath()
+	}
+	return &Log{path: path}
+}
+
+// Path returns the file the log is written to.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append adds an entry to the end of the log.
+func (l *Log) Append(entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Read returns the entries matching filter, oldest first. A missing file is
+// an empty history, and lines that can't be parsed (such as one cut short by
+// a crash) are skipped.
+func (l *Log) Read(filter Filter) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if filter.Match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries, nil
+}
+
+// Filter selects history entries. Zero values match everything.
+type Filter struct {
+	Since   time.Time
+	Until   time.Time
+	Barcode string
+	Source  string
+	Profile string
+	Outcome string
+	// Failed only matches scans that didn't play or run anything
+	Failed bool
+	// Search matches a case-insensitive substring of the barcode, album,
+	// artist or error
+	Search string
+	// Limit keeps only the most recent matches
+	Limit int
+}
+
+// Match reports whether an entry passes the filter. Limit isn't applied.
+func (f Filter) Match(e Entry) bool {
+	switch {
+	case !f.Since.IsZero() && e.Time.Before(f.Since),
+		!f.Until.IsZero() && !e.Time.Before(f.Until),
+		f.Barcode != "" && e.Barcode != f.Barcode,
+		f.Source != "" && e.Source != f.Source,
+		f.Profile != "" && e.Profile != f.Profile,
+		f.Outcome != "" && e.Outcome != f.Outcome,
+		f.Failed && !e.Failed():
+		return false
+	}
+
+	if f.Search != "" {
+		search := strings.ToLower(f.Search)
+		for _, field := range []string{e.Barcode, e.Album, e.Artist, e.Error} {
+			if strings.Contains(strings.ToLower(field), search) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// ParseTime parses a point in time given as a date (2006-01-02), an RFC 3339
+// timestamp, or a duration before now such as 36h or 7d.
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q, expected a date, a timestamp or a duration like 7d", value)
+}
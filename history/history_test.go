@@ -0,0 +1,137 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterMatch(t *testing.T) {
+	noon := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	entry := Entry{
+		Time:    noon,
+		Barcode: "5099902987729",
+		Source:  "keyboard",
+		Profile: "kitchen",
+		Album:   "Kind of Blue",
+		Artist:  "Miles Davis",
+		Outcome: OutcomeNotOnSpotify,
+		Error:   "no matching album on Spotify",
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty filter", Filter{}, true},
+		{"since before", Filter{Since: noon.Add(-time.Hour)}, true},
+		{"since equal", Filter{Since: noon}, true},
+		{"since after", Filter{Since: noon.Add(time.Second)}, false},
+		{"until after", Filter{Until: noon.Add(time.Second)}, true},
+		{"until equal", Filter{Until: noon}, false},
+		{"until before", Filter{Until: noon.Add(-time.Hour)}, false},
+		{"within range", Filter{Since: noon.Add(-time.Hour), Until: noon.Add(time.Hour)}, true},
+		{"barcode", Filter{Barcode: "5099902987729"}, true},
+		{"other barcode", Filter{Barcode: "0602498611786"}, false},
+		{"source", Filter{Source: "keyboard"}, true},
+		{"other source", Filter{Source: "http"}, false},
+		{"profile", Filter{Profile: "kitchen"}, true},
+		{"other profile", Filter{Profile: "office"}, false},
+		{"outcome", Filter{Outcome: OutcomeNotOnSpotify}, true},
+		{"other outcome", Filter{Outcome: OutcomePlayed}, false},
+		{"failed", Filter{Failed: true}, true},
+		{"search album", Filter{Search: "kind of"}, true},
+		{"search artist case", Filter{Search: "MILES"}, true},
+		{"search error", Filter{Search: "spotify"}, true},
+		{"search barcode", Filter{Search: "98772"}, true},
+		{"search missing", Filter{Search: "coltrane"}, false},
+		{"search with other field failing", Filter{Search: "miles", Source: "http"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.Match(entry); got != tt.want {
+			t.Errorf("%s: Match = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilterMatchFailed(t *testing.T) {
+	tests := []struct {
+		outcome string
+		want    bool
+	}{
+		{OutcomePlayed, false},
+		{OutcomeQueued, false},
+		{OutcomePaused, false},
+		{OutcomeResumed, false},
+		{OutcomeSkipped, false},
+		{OutcomeIgnored, false},
+		{OutcomeCommand, false},
+		{OutcomeDryRun, false},
+		{OutcomeCataloged, false},
+		{OutcomeNotFound, true},
+		{OutcomeNotOnSpotify, true},
+		{OutcomeRegionRestricted, true},
+		{OutcomeNoDevice, true},
+		{OutcomePremiumRequired, true},
+		{OutcomeFailed, true},
+	}
+
+	filter := Filter{Failed: true}
+	for _, tt := range tests {
+		if got := filter.Match(Entry{Outcome: tt.outcome}); got != tt.want {
+			t.Errorf("Failed filter on %s = %v, want %v", tt.outcome, got, tt.want)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"7d", time.Date(2026, time.March, 3, 12, 30, 0, 0, time.UTC)},
+		{"0d", now},
+		{"36h", time.Date(2026, time.March, 9, 0, 30, 0, 0, time.UTC)},
+		{"90m", time.Date(2026, time.March, 10, 11, 0, 0, 0, time.UTC)},
+		{"2026-02-28", time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC)},
+		{"2026-03-01T08:15:00Z", time.Date(2026, time.March, 1, 8, 15, 0, 0, time.UTC)},
+		{"2026-03-01T08:15:00+02:00", time.Date(2026, time.March, 1, 6, 15, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTime(tt.value, now)
+		if err != nil {
+			t.Errorf("ParseTime(%q): %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimeLocalDate(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, loc)
+
+	got, err := ParseTime("2026-03-01", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, time.March, 1, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("ParseTime = %s, want midnight in the local zone %s", got, want)
+	}
+}
+
+func TestParseTimeInvalid(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	for _, value := range []string{"", "d", "xd", "yesterday", "2026-13-01", "10/03/2026"} {
+		if _, err := ParseTime(value, now); err == nil {
+			t.Errorf("ParseTime(%q) succeeded, want an error", value)
+		}
+	}
+}
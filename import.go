@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"barcode-music-player/importer"
+	"barcode-music-player/spotify"
+)
+
+func runImport(args []string) error {
+	f := newFlags("import", "<file>")
+	progressFile := f.String("progress", "", "progress file to resume from (default: <file>.progress.jsonl)")
+	fresh := f.Bool("fresh", false, "ignore the progress file and resolve every barcode again")
+	minConfidence := f.Float64("min-confidence", importer.DefaultMinConfidence, "confidence below which a match is ambiguous, between 0 and 1")
+	report := f.String("report", "", "write a CSV report of every barcode to this file")
+	playlist := f.String("playlist", "", "add the matched albums to this playlist, creating it if needed")
+	save := f.Bool("save", false, "save the matched albums to your Spotify library")
+	ambiguous := f.Bool("include-ambiguous", false, "also add the best candidate of ambiguous barcodes")
+
+	if err := f.parse(args, 1); err != nil {
+		return err
+	}
+	if *minConfidence <= 0 || *minConfidence > 1 {
+		return fail(exitUsage, fmt.Errorf("--min-confidence must be between 0 and 1"))
+	}
+
+	if err := f.loadConfig(); err != nil {
+		return err
+	}
+	out := f.progress()
+
+	file, err := os.Open(f.Arg(0))
+	if err != nil {
+		return fail(exitUsage, err)
+	}
+	barcodes, invalid, err := importer.ReadBarcodes(file)
+	file.Close()
+	if err != nil {
+		return fail(exitUsage, err)
+	}
+	for _, value := range invalid {
+		fmt.Fprintf(out, "⚠️  Skipping %q: not a barcode\n", value)
+	}
+	if len(barcodes) == 0 {
+		return fail(exitUsage, fmt.Errorf("no barcodes in %s", f.Arg(0)))
+	}
+
+	if *progressFile == "" {
+		*progressFile = importer.ProgressPath(f.Arg(0))
+	}
+	progress := importer.OpenProgress(*progressFile)
+	if *fresh {
+		if err := progress.Reset(); err != nil {
+			return err
+		}
+	}
+	items, err := progress.Load()
+	if err != nil {
+		return err
+	}
+
+	p, err := newPlayer(out)
+	if err != nil {
+		return err
+	}
+
+	// Check the permissions before spending an hour on resolving
+	var scopes []string
+	if *save {
+		scopes = append(scopes, spotify.ScopeLibraryModify)
+	}
+	if *playlist != "" {
+		scopes = append(scopes, spotify.ScopePlaylistReadPrivate, spotify.ScopePlaylistModifyPrivate)
+	}
+	if missing := spotifyClient.MissingScopes(scopes...); len(missing) > 0 {
+		return fail(exitAuth, fmt.Errorf("the Spotify login lacks %s, run 'barcode-music-player login' again", strings.Join(missing, ", ")))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	opts := importer.Options{MinConfidence: *minConfidence}
+	interrupted := false
+	for i, barcode := range barcodes {
+		if item, ok := items[barcode]; ok && item.Done() {
+			continue
+		}
+		if ctx.Err() != nil {
+			interrupted = true
+			break
+		}
+
+		fmt.Fprintf(out, "\n📦 [%d/%d] %s\n", i+1, len(barcodes), barcode)
+		res, err := p.Resolve(ctx, barcode, "cli")
+		item := importer.Classify(barcode, res, err, opts)
+		items[barcode] = item
+		if err := progress.Append(item); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "   → %s\n", describeItem(item))
+	}
+
+	ordered := make([]importer.Item, 0, len(barcodes))
+	for _, barcode := range barcodes {
+		if item, ok := items[barcode]; ok {
+			ordered = append(ordered, item)
+		}
+	}
+	summary := importer.Summarize(barcodes, items)
+
+	if *report != "" {
+		if err := writeImportReport(*report, ordered); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "📝 Wrote the report to %s\n", *report)
+	}
+
+	if interrupted {
+		if err := printImportSummary(summary, ordered, f.json); err != nil {
+			return err
+		}
+		return fmt.Errorf("interrupted, run the same command again to resume from %s", progress.Path())
+	}
+
+	var albumURIs, albumIDs []string
+	for _, item := range ordered {
+		if item.AlbumURI == "" || (item.Status != importer.StatusMatched && !(*ambiguous && item.Status == importer.StatusAmbiguous)) {
+			continue
+		}
+		if id, ok := spotify.ParseAlbumLink(item.AlbumURI); ok {
+			albumURIs = append(albumURIs, item.AlbumURI)
+			albumIDs = append(albumIDs, id)
+		}
+	}
+
+	if cfg.DryRun && (*save || *playlist != "") {
+		fmt.Fprintf(out, "🧪 Dry run: would add %d albums\n", len(albumURIs))
+		*save, *playlist = false, ""
+	}
+
+	if *save && len(albumIDs) > 0 {
+		if err := spotifyClient.SaveAlbums(ctx, albumIDs); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "💚 Saved %d albums to your Spotify library\n", len(albumIDs))
+	}
+
+	if *playlist != "" && len(albumURIs) > 0 {
+		added, err := p.AddToPlaylist(ctx, *playlist, albumURIs)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "📋 Added %d albums to \"%s\" (%d were already in it)\n", added, *playlist, len(albumURIs)-added)
+	}
+
+	return printImportSummary(summary, ordered, f.json)
+}
+
+// describeItem summarizes the outcome of importing a barcode.
+func describeItem(item importer.Item) string {
+	switch item.Status {
+	case importer.StatusMatched:
+		return fmt.Sprintf("✅ %s · %s (%.0f%%)", item.Album, item.Artist, item.Confidence*100)
+	case importer.StatusAmbiguous:
+		return fmt.Sprintf("🤔 %s · %s (%.0f%%): %s", item.Album, item.Artist, item.Confidence*100, item.Reason)
+	case importer.StatusUnmatched:
+		return "❌ " + item.Reason
+	}
+	return "⚠️  " + item.Reason
+}
+
+func writeImportReport(path string, items []importer.Item) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+
+	if err := importer.WriteCSV(file, items); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return file.Close()
+}
+
+func printImportSummary(summary importer.Summary, items []importer.Item, asJSON bool) error {
+	if asJSON {
+		return printJSON(struct {
+			Summary importer.Summary `json:"summary"`
+			Items   []importer.Item  `json:"items"`
+		}{summary, items})
+	}
+
+	fmt.Println()
+	fmt.Printf("📊 %d barcodes: %d matched, %d ambiguous, %d unmatched, %d failed, %d pending\n",
+		summary.Total, summary.Matched, summary.Ambiguous, summary.Unmatched, summary.Failed, summary.Pending)
+
+	// List what needs checking by hand
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := false
+	for _, item := range items {
+		if item.Status == importer.StatusMatched {
+			continue
+		}
+		if !header {
+			fmt.Fprintln(w, "BARCODE\tSTATUS\tCONFIDENCE\tALBUM\tREASON")
+			header = true
+		}
+		album := ""
+		if item.Album != "" {
+			album = item.Album + " · " + item.Artist
+		} else if item.Release != "" {
+			album = item.Release + " · " + item.ReleaseArtist
+		}
+		reason, _, _ := strings.Cut(item.Reason, "\n")
+		fmt.Fprintf(w, "%s\t%s\t%.0f%%\t%s\t%s\n", item.Barcode, item.Status, item.Confidence*100, album, reason)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,268 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"barcode-music-player/musicbrainz"
+	"barcode-music-player/normalize"
+	"barcode-music-player/player"
+	"barcode-music-player/spotify"
+)
+
+// Statuses of an imported barcode.
+const (
+	StatusMatched = "matched"
+	// StatusAmbiguous is a match too weak, or too close to another album, to
+	// be trusted without checking
+	StatusAmbiguous = "ambiguous"
+	// StatusUnmatched means MusicBrainz or Spotify has no album for the
+	// barcode
+	StatusUnmatched = "unmatched"
+	// StatusFailed is an error such as a network failure; failed barcodes are
+	// retried when the import is resumed
+	StatusFailed = "failed"
+)
+
+// Defaults for Options.
+const (
+	DefaultMinConfidence = 0.7
+	// DefaultMargin is how many points a runner-up with a different title or
+	// artist may trail the best candidate by before the match is ambiguous
+	DefaultMargin = 5
+)
+
+// maxScore is the score of a candidate matching the title, main artist,
+// release type and track count, which counts as full confidence.
+const maxScore = 100
+
+// Options tune when a match is trusted.
+type Options struct {
+	MinConfidence float64
+	Margin        float64
+}
+
+// Item is the outcome of importing a barcode.
+type Item struct {
+	Barcode string    `json:"barcode"`
+	Time    time.Time `json:"time"`
+	Status  string    `json:"status"`
+	// Confidence is between 0 and 1; albums linked from MusicBrainz or
+	// mapped by hand are certain
+	Confidence float64 `json:"confidence"`
+	// Reason explains ambiguous, unmatched and failed items
+	Reason string `json:"reason,omitempty"`
+
+	ReleaseID     string `json:"release_id,omitempty"`
+	Release       string `json:"release,omitempty"`
+	ReleaseArtist string `json:"release_artist,omitempty"`
+
+	AlbumURI string `json:"album_uri,omitempty"`
+	Album    string `json:"album,omitempty"`
+	Artist   string `json:"artist,omitempty"`
+	// Alternatives are the runners-up of ambiguous matches
+	Alternatives []Alternative `json:"alternatives,omitempty"`
+}
+
+// Alternative is another album an ambiguous barcode could be.
+type Alternative struct {
+	AlbumURI   string  `json:"album_uri"`
+	Album      string  `json:"album"`
+	Artist     string  `json:"artist"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Done reports whether the item needn't be resolved again when resuming.
+func (i Item) Done() bool {
+	return i.Status != StatusFailed
+}
+
+// Classify turns the resolution of a barcode into an import item.
+func Classify(barcode string, res *player.Resolution, err error, opts Options) Item {
+	if opts.MinConfidence == 0 {
+		opts.MinConfidence = DefaultMinConfidence
+	}
+	if opts.Margin == 0 {
+		opts.Margin = DefaultMargin
+	}
+
+	item := Item{Barcode: barcode, Time: time.Now()}
+	if res != nil && res.Release != nil {
+		item.ReleaseID = res.Release.ID
+		item.Release = res.Release.Title
+		item.ReleaseArtist = res.Release.GetMainArtist()
+	}
+
+	if err != nil {
+		item.Reason = err.Error()
+		switch {
+		case errors.Is(err, musicbrainz.ErrNotFound),
+			errors.Is(err, player.ErrNotOnSpotify),
+			errors.Is(err, spotify.ErrRegionRestricted):
+			item.Status = StatusUnmatched
+		default:
+			item.Status = StatusFailed
+		}
+		return item
+	}
+
+	item.AlbumURI = res.Album.URI
+	item.Album = res.Album.Name
+	item.Artist = res.Album.GetMainArtist()
+
+	// Without candidates the album was mapped or linked from MusicBrainz
+	if len(res.Candidates) == 0 {
+		item.Status = StatusMatched
+		item.Confidence = 1
+		return item
+	}
+
+	best := res.Candidates[0]
+	item.Confidence = confidence(best.Score)
+	item.Status = StatusMatched
+
+	if item.Confidence < opts.MinConfidence {
+		item.Status = StatusAmbiguous
+		item.Reason = fmt.Sprintf("confidence below %.0f%% (%s)", opts.MinConfidence*100, strings.Join(best.Reasons, ", "))
+	}
+
+	for _, candidate := range res.Candidates[1:] {
+		if best.Score-candidate.Score > opts.Margin {
+			break
+		}
+		if sameAlbum(best.Album, candidate.Album) {
+			continue
+		}
+
+		if item.Status == StatusMatched {
+			item.Status = StatusAmbiguous
+			item.Reason = fmt.Sprintf("\"%s\" by %s scores almost as well", candidate.Album.Name, candidate.Album.GetMainArtist())
+		}
+		item.Alternatives = append(item.Alternatives, Alternative{
+			AlbumURI:   candidate.Album.URI,
+			Album:      candidate.Album.Name,
+			Artist:     candidate.Album.GetMainArtist(),
+			Confidence: confidence(candidate.Score),
+		})
+	}
+
+	return item
+}
+
+func confidence(score float64) float64 {
+	return max(0, min(score/maxScore, 1))
+}
+
+// sameAlbum reports whether two albums only differ by edition, which doesn't
+// make a match ambiguous.
+func sameAlbum(a, b spotify.Album) bool {
+	return normalize.Key(a.Name) == normalize.Key(b.Name) &&
+		normalize.Key(a.GetMainArtist()) == normalize.Key(b.GetMainArtist())
+}
+
+// barcodeColumns are the header names of the barcode column in CSV files.
+var barcodeColumns = []string{"barcode", "upc", "ean", "gtin"}
+
+// ReadBarcodes reads barcodes from a text file with one per line, or from a
+// CSV file separated by commas, semicolons or tabs, taking the column headed
+// barcode, upc, ean or gtin, or else the first column. Spaces and dashes are
+// removed, and blank lines, lines starting with # and duplicates are skipped.
+// Values that aren't barcodes are returned as invalid.
+func ReadBarcodes(r io.Reader) (barcodes, invalid []string, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read barcodes: %w", err)
+	}
+
+	// Spreadsheets often save CSV files with a byte order mark
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = delimiter(string(data))
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read barcodes: %w", err)
+	}
+
+	column := 0
+	if len(records) > 0 {
+		for i, name := range records[0] {
+			if containsString(barcodeColumns, strings.ToLower(strings.TrimSpace(name))) {
+				column = i
+				records = records[1:]
+				break
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, record := range records {
+		if column >= len(record) {
+			continue
+		}
+
+		value := strings.TrimSpace(record[column])
+		barcode := strings.NewReplacer(" ", "", "-", "").Replace(value)
+		switch {
+		case barcode == "" || seen[barcode]:
+		case !isBarcode(barcode):
+			invalid = append(invalid, value)
+		default:
+			seen[barcode] = true
+			barcodes = append(barcodes, barcode)
+		}
+	}
+
+	return barcodes, invalid, nil
+}
+
+// delimiter guesses the field separator from the first line that isn't a
+// comment.
+func delimiter(data string) rune {
+	var line string
+	for _, line = range strings.Split(data, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			break
+		}
+	}
+
+	switch {
+	case strings.Contains(line, "\t"):
+		return '\t'
+	case strings.Contains(line, ";") && !strings.Contains(line, ","):
+		return ';'
+	}
+	return ','
+}
+
+// isBarcode accepts UPC and EAN codes: 8 to 14 digits.
+func isBarcode(value string) bool {
+	if len(value) < 8 || len(value) > 14 {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadBarcodes(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantCodes   []string
+		wantInvalid []string
+	}{
+		{
+			name:      "one per line",
+			input:     "5099902988023\n0602547670342\r\n\n724384260927\n",
+			wantCodes: []string{"5099902988023", "0602547670342", "724384260927"},
+		},
+		{
+			name:      "comments, spaces, dashes and duplicates",
+			input:     "# shelf A\n5 099902 988023\n  0602-5476-70342\n5099902988023\n",
+			wantCodes: []string{"5099902988023", "0602547670342"},
+		},
+		{
+			name:        "invalid values",
+			input:       "5099902988023\nnot a barcode\n1234\n123456789012345\n",
+			wantCodes:   []string{"5099902988023"},
+			wantInvalid: []string{"not a barcode", "1234", "123456789012345"},
+		},
+		{
+			name:      "CSV with a barcode column",
+			input:     "Artist,Title,Barcode\nThe Beatles,Abbey Road,5099902988023\n\"Simon, Paul\",Graceland,0602547670342\n",
+			wantCodes: []string{"5099902988023", "0602547670342"},
+		},
+		{
+			name:      "semicolons and a UPC column",
+			input:     "title;UPC\nAbbey Road;5099902988023\n",
+			wantCodes: []string{"5099902988023"},
+		},
+		{
+			name:      "tabs and a byte order mark",
+			input:     "\ufeffEAN\tTitle\n5099902988023\tAbbey Road\n",
+			wantCodes: []string{"5099902988023"},
+		},
+		{
+			name:      "CSV without a header uses the first column",
+			input:     "5099902988023,Abbey Road\n0602547670342,Graceland\n",
+			wantCodes: []string{"5099902988023", "0602547670342"},
+		},
+		{
+			name:        "header without a barcode column",
+			input:       "Title,Artist\nAbbey Road,The Beatles\n",
+			wantInvalid: []string{"Title", "Abbey Road"},
+		},
+		{
+			name:      "short rows",
+			input:     "Title,Barcode\nAbbey Road\nGraceland,0602547670342\n",
+			wantCodes: []string{"0602547670342"},
+		},
+		{
+			name:  "empty",
+			input: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes, invalid, err := ReadBarcodes(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equal(codes, tt.wantCodes) {
+				t.Errorf("barcodes %q, want %q", codes, tt.wantCodes)
+			}
+			if !equal(invalid, tt.wantInvalid) {
+				t.Errorf("invalid %q, want %q", invalid, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Progress is an append-only JSON Lines file of imported items, so an
+// interrupted import can resume where it stopped. When a barcode appears more
+// than once, the last item wins.
+type Progress struct {
+	path string
+	mu   sync.Mutex
+}
+
+// ProgressPath returns the default progress file of an import file.
+func ProgressPath(file string) string {
+	return file + ".progress.jsonl"
+}
+
+// OpenProgress returns the progress file at path. The file is created by the
+// first Append.
+func OpenProgress(path string) *Progress {
+	return &Progress{path: path}
+}
+
+// Path returns the file the progress is written to.
+func (p *Progress) Path() string {
+	return p.path
+}
+
+// Load returns the items imported so far by barcode. A missing file is no
+// progress, and lines that can't be parsed (such as one cut short by an
+// interruption) are skipped.
+func (p *Progress) Load() (map[string]Item, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	items := make(map[string]Item)
+
+	file, err := os.Open(p.path)
+	if os.IsNotExist(err) {
+		return items, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open progress: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var item Item
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil || item.Barcode == "" {
+			continue
+		}
+		items[item.Barcode] = item
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read progress: %w", err)
+	}
+
+	return items, nil
+}
+
+// Append records an imported item.
+func (p *Progress) Append(item Item) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	file, err := os.OpenFile(p.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open progress: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write progress: %w", err)
+	}
+	return nil
+}
+
+// Reset deletes the progress file to start over.
+func (p *Progress) Reset() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := os.Remove(p.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove progress: %w", err)
+	}
+	return nil
+}
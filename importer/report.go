@@ -0,0 +1,76 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Summary counts items by status.
+type Summary struct {
+	Total     int `json:"total"`
+	Matched   int `json:"matched"`
+	Ambiguous int `json:"ambiguous"`
+	Unmatched int `json:"unmatched"`
+	Failed    int `json:"failed"`
+	// Pending barcodes weren't resolved yet, as when the import was
+	// interrupted
+	Pending int `json:"pending"`
+}
+
+// Summarize counts the items of the barcodes, in the order of the import
+// file.
+func Summarize(barcodes []string, items map[string]Item) Summary {
+	summary := Summary{Total: len(barcodes)}
+	for _, barcode := range barcodes {
+		item, ok := items[barcode]
+		if !ok {
+			summary.Pending++
+			continue
+		}
+
+		switch item.Status {
+		case StatusMatched:
+			summary.Matched++
+		case StatusAmbiguous:
+			summary.Ambiguous++
+		case StatusUnmatched:
+			summary.Unmatched++
+		default:
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+var csvHeader = []string{
+	"barcode", "status", "confidence", "album", "artist", "album_uri",
+	"release", "release_artist", "release_id", "alternatives", "reason",
+}
+
+// WriteCSV writes the items as a CSV report with a header row.
+func WriteCSV(w io.Writer, items []Item) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		var alternatives []string
+		for _, alt := range item.Alternatives {
+			alternatives = append(alternatives, fmt.Sprintf("%s · %s (%.0f%%, %s)", alt.Album, alt.Artist, alt.Confidence*100, alt.AlbumURI))
+		}
+
+		record := []string{
+			item.Barcode, item.Status, fmt.Sprintf("%.2f", item.Confidence), item.Album, item.Artist, item.AlbumURI,
+			item.Release, item.ReleaseArtist, item.ReleaseID, strings.Join(alternatives, "; "), item.Reason,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
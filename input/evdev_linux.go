@@ -0,0 +1,102 @@
+//go:build linux
+
+package input
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+)
+
+const (
+	evKey      = 0x01
+	keyPressed = 1
+	keyEnter   = 28
+	keyKPEnter = 96
+
+	// EVIOCGRAB, _IOW('E', 0x90, int)
+	eviocgrab = 0x40044590
+)
+
+// evdevKeys maps Linux key codes to the characters barcode scanners type.
+var evdevKeys = map[uint16]byte{
+	2: '1', 3: '2', 4: '3', 5: '4', 6: '5', 7: '6', 8: '7', 9: '8', 10: '9', 11: '0',
+	12: '-',
+	16: 'q', 17: 'w', 18: 'e', 19: 'r', 20: 't', 21: 'y', 22: 'u', 23: 'i', 24: 'o', 25: 'p',
+	30: 'a', 31: 's', 32: 'd', 33: 'f', 34: 'g', 35: 'h', 36: 'j', 37: 'k', 38: 'l',
+	44: 'z', 45: 'x', 46: 'c', 47: 'v', 48: 'b', 49: 'n', 50: 'm',
+	71: '7', 72: '8', 73: '9', 75: '4', 76: '5', 77: '6', 79: '1', 80: '2', 81: '3', 82: '0',
+}
+
+// inputEvent mirrors struct input_event on 64-bit Linux.
+type inputEvent struct {
+	Sec   int64
+	Usec  int64
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// Evdev reads scans straight from a keyboard-emulating scanner's
+// /dev/input/event* device. The device is grabbed exclusively so scans do not
+// also end up as keystrokes in whatever has focus.
+type Evdev struct {
+	device string
+}
+
+func NewEvdev(device string) *Evdev {
+	return &Evdev{device: device}
+}
+
+func (e *Evdev) Name() string {
+	return "evdev"
+}
+
+func (e *Evdev) Run(ctx context.Context, events chan<- Event) error {
+	file, err := os.Open(e.device)
+	if err != nil {
+		return fmt.Errorf("failed to open input device %s: %w", e.device, err)
+	}
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), eviocgrab, 1); errno != 0 {
+		file.Close()
+		return fmt.Errorf("failed to grab input device %s: %w", e.device, errno)
+	}
+
+	// Closing the device is the only way to interrupt a blocking read
+	go func() {
+		<-ctx.Done()
+		file.Close()
+	}()
+
+	var barcode strings.Builder
+	for {
+		var event inputEvent
+		if err := binary.Read(file, binary.LittleEndian, &event); err != nil {
+			if err == io.EOF || ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to read input device: %w", err)
+		}
+
+		if event.Type != evKey || event.Value != keyPressed {
+			continue
+		}
+
+		if event.Code == keyEnter || event.Code == keyKPEnter {
+			if barcode.Len() > 0 && !emit(ctx, events, e.Name(), barcode.String()) {
+				return nil
+			}
+			barcode.Reset()
+			continue
+		}
+
+		if char, ok := evdevKeys[event.Code]; ok {
+			barcode.WriteByte(char)
+		}
+	}
+}
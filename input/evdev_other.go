@@ -0,0 +1,24 @@
+//go:build !linux
+
+package input
+
+import (
+	"context"
+	"fmt"
+)
+
+type Evdev struct {
+	device string
+}
+
+func NewEvdev(device string) *Evdev {
+	return &Evdev{device: device}
+}
+
+func (e *Evdev) Name() string {
+	return "evdev"
+}
+
+func (e *Evdev) Run(ctx context.Context, events chan<- Event) error {
+	return fmt.Errorf("evdev input is only supported on Linux")
+}
@@ -0,0 +1,127 @@
+package input
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTP accepts scans posted to /scan, either as a JSON body
+// ({"barcode": "..."}), a "barcode" form value or a plain-text body.
+//
+// Any web page open in a browser can post forms and plain text to a local
+// address, so with a token those requests must carry it like the API's, and
+// without one only JSON is accepted: browsers won't send it to another origin
+// without a CORS preflight, which the server doesn't allow.
+type HTTP struct {
+	addr  string
+	token string
+}
+
+func NewHTTP(addr, token string) *HTTP {
+	return &HTTP{addr: addr, token: token}
+}
+
+func (h *HTTP) Name() string {
+	return "http"
+}
+
+func (h *HTTP) Run(ctx context.Context, events chan<- Event) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/scan", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if status, err := h.authorize(r); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		barcode, err := readBarcode(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !emit(ctx, events, h.Name(), barcode) {
+			http.Error(w, "shutting down", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	server := &http.Server{
+		Addr:    h.addr,
+		Handler: mux,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("failed to start HTTP input: %w", err)
+	}
+
+	return nil
+}
+
+// authorize checks the token if there is one, and otherwise that the scan
+// couldn't have been sent by a web page.
+func (h *HTTP) authorize(r *http.Request) (int, error) {
+	if h.token == "" {
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			return http.StatusUnsupportedMediaType, fmt.Errorf("scans must be posted as JSON unless API_TOKEN is set")
+		}
+		return 0, nil
+	}
+
+	token := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		return http.StatusUnauthorized, fmt.Errorf("invalid or missing API token")
+	}
+	return 0, nil
+}
+
+func readBarcode(r *http.Request) (string, error) {
+	var barcode string
+
+	switch {
+	case strings.HasPrefix(r.Header.Get("Content-Type"), "application/json"):
+		var body struct {
+			Barcode string `json:"barcode"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return "", fmt.Errorf("invalid JSON body: %w", err)
+		}
+		barcode = body.Barcode
+	case strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded"):
+		barcode = r.FormValue("barcode")
+	default:
+		data, err := io.ReadAll(io.LimitReader(r.Body, 1024))
+		if err != nil {
+			return "", fmt.Errorf("failed to read body: %w", err)
+		}
+		barcode = string(data)
+	}
+
+	barcode = strings.TrimSpace(barcode)
+	if barcode == "" {
+		return "", fmt.Errorf("barcode is required")
+	}
+
+	return barcode, nil
+}
@@ -0,0 +1,113 @@
+package input
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Event struct {
+	Barcode   string
+	Source    string
+	Timestamp time.Time
+}
+
+// Input is a source of barcode scans. Run blocks until the source is
+// exhausted or ctx is cancelled, sending every scan to events.
+type Input interface {
+	Name() string
+	Run(ctx context.Context, events chan<- Event) error
+}
+
+// Multiplexer merges several inputs into one stream. An identical barcode is
+// dropped if it arrives within the debounce window of the previous one,
+// regardless of which source reported it.
+type Multiplexer struct {
+	sources  []Input
+	debounce time.Duration
+	lastSeen map[string]time.Time
+}
+
+func NewMultiplexer(debounce time.Duration, sources ...Input) *Multiplexer {
+	return &Multiplexer{
+		sources:  sources,
+		debounce: debounce,
+		lastSeen: make(map[string]time.Time),
+	}
+}
+
+// Run starts every source and returns a single channel with their scans in
+// arrival order. The channel is closed once all sources have stopped.
+func (m *Multiplexer) Run(ctx context.Context) <-chan Event {
+	raw := make(chan Event)
+	out := make(chan Event)
+
+	var wg sync.WaitGroup
+	for _, source := range m.sources {
+		wg.Add(1)
+		go func(source Input) {
+			defer wg.Done()
+			if err := source.Run(ctx, raw); err != nil && ctx.Err() == nil {
+				fmt.Printf("⚠️  Input %s stopped: %v\n", source.Name(), err)
+			}
+		}(source)
+	}
+
+	go func() {
+		wg.Wait()
+		close(raw)
+	}()
+
+	go func() {
+		defer close(out)
+		for event := range raw {
+			if m.isDuplicate(event) {
+				continue
+			}
+
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// isDuplicate reports whether a scan repeats an accepted one within the
+// debounce window. Only accepted scans start a new window, so a barcode held
+// under the scanner is accepted again once the window has passed.
+func (m *Multiplexer) isDuplicate(event Event) bool {
+	if last, seen := m.lastSeen[event.Barcode]; seen && event.Timestamp.Sub(last) < m.debounce {
+		return true
+	}
+
+	// Forget the scans whose window has passed
+	for barcode, last := range m.lastSeen {
+		if event.Timestamp.Sub(last) >= m.debounce {
+			delete(m.lastSeen, barcode)
+		}
+	}
+
+	m.lastSeen[event.Barcode] = event.Timestamp
+	return false
+}
+
+// emit sends a scan to events, giving up if ctx is cancelled first.
+func emit(ctx context.Context, events chan<- Event, source, barcode string) bool {
+	event := Event{
+		Barcode:   barcode,
+		Source:    source,
+		Timestamp: time.Now(),
+	}
+
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,132 @@
+package input
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestIsDuplicate(t *testing.T) {
+	start := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	scan := func(barcode, source string, after time.Duration) Event {
+		return Event{Barcode: barcode, Source: source, Timestamp: start.Add(after)}
+	}
+
+	tests := []struct {
+		name   string
+		events []Event
+		want   []bool
+	}{
+		{
+			name:   "repeat within the window",
+			events: []Event{scan("1", "keyboard", 0), scan("1", "keyboard", time.Second)},
+			want:   []bool{false, true},
+		},
+		{
+			name:   "repeat at the end of the window",
+			events: []Event{scan("1", "keyboard", 0), scan("1", "keyboard", 2*time.Second)},
+			want:   []bool{false, false},
+		},
+		{
+			name:   "repeat after the window",
+			events: []Event{scan("1", "keyboard", 0), scan("1", "keyboard", 5*time.Second)},
+			want:   []bool{false, false},
+		},
+		{
+			name:   "same barcode from another source",
+			events: []Event{scan("1", "keyboard", 0), scan("1", "serial", time.Second)},
+			want:   []bool{false, true},
+		},
+		{
+			name:   "different barcodes from different sources",
+			events: []Event{scan("1", "keyboard", 0), scan("2", "serial", 0), scan("1", "http", time.Second), scan("2", "keyboard", time.Second)},
+			want:   []bool{false, false, true, true},
+		},
+		{
+			name: "held under the scanner",
+			events: []Event{
+				scan("1", "keyboard", 0),
+				scan("1", "keyboard", 1500*time.Millisecond),
+				scan("1", "keyboard", 2500*time.Millisecond),
+				scan("1", "keyboard", 3*time.Second),
+				scan("1", "keyboard", 4*time.Second),
+			},
+			// Dropped scans don't extend the window
+			want: []bool{false, true, false, true, true},
+		},
+		{
+			name: "windows are per barcode",
+			events: []Event{
+				scan("1", "keyboard", 0),
+				scan("2", "serial", 1500*time.Millisecond),
+				scan("1", "http", 2500*time.Millisecond),
+				scan("2", "keyboard", 3*time.Second),
+			},
+			want: []bool{false, false, false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		m := NewMultiplexer(2 * time.Second)
+		for i, event := range tt.events {
+			if got := m.isDuplicate(event); got != tt.want[i] {
+				t.Errorf("%s: scan %d of %s from %s: isDuplicate = %v, want %v",
+					tt.name, i, event.Barcode, event.Source, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestIsDuplicateForgetsPassedWindows(t *testing.T) {
+	start := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	m := NewMultiplexer(time.Second)
+
+	m.isDuplicate(Event{Barcode: "1", Timestamp: start})
+	m.isDuplicate(Event{Barcode: "2", Timestamp: start.Add(500 * time.Millisecond)})
+	m.isDuplicate(Event{Barcode: "3", Timestamp: start.Add(1200 * time.Millisecond)})
+
+	if _, ok := m.lastSeen["1"]; ok {
+		t.Error("scan of 1 kept after its window passed")
+	}
+	if _, ok := m.lastSeen["2"]; !ok {
+		t.Error("scan of 2 forgotten within its window")
+	}
+}
+
+// fakeInput sends its scans one after another, then stops.
+type fakeInput struct {
+	name     string
+	barcodes []string
+}
+
+func (f fakeInput) Name() string {
+	return f.name
+}
+
+func (f fakeInput) Run(ctx context.Context, events chan<- Event) error {
+	for _, barcode := range f.barcodes {
+		if !emit(ctx, events, f.name, barcode) {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func TestMultiplexerDropsDuplicatesAcrossSources(t *testing.T) {
+	m := NewMultiplexer(time.Minute,
+		fakeInput{name: "keyboard", barcodes: []string{"1", "2", "1"}},
+		fakeInput{name: "serial", barcodes: []string{"2", "3"}},
+		fakeInput{name: "http", barcodes: []string{"1", "3", "4"}},
+	)
+
+	var got []string
+	for event := range m.Run(context.Background()) {
+		got = append(got, event.Barcode)
+	}
+
+	slices.Sort(got)
+	if want := []string{"1", "2", "3", "4"}; !slices.Equal(got, want) {
+		t.Errorf("barcodes = %v, want %v", got, want)
+	}
+}
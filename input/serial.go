@@ -0,0 +1,40 @@
+package input
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Serial reads scans from a serial barcode scanner. The port must already be
+// configured (e.g. with stty) for the scanner's baud rate.
+type Serial struct {
+	device string
+}
+
+func NewSerial(device string) *Serial {
+	return &Serial{device: device}
+}
+
+func (s *Serial) Name() string {
+	return "serial"
+}
+
+func (s *Serial) Run(ctx context.Context, events chan<- Event) error {
+	port, err := os.Open(s.device)
+	if err != nil {
+		return fmt.Errorf("failed to open serial device %s: %w", s.device, err)
+	}
+
+	// Closing the port is the only way to interrupt a blocking read
+	go func() {
+		<-ctx.Done()
+		port.Close()
+	}()
+
+	if err := scanLines(ctx, port, events, s.Name()); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read from serial device: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package input
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+)
+
+type Stdin struct {
+	reader io.Reader
+}
+
+func NewStdin() *Stdin {
+	return &Stdin{reader: os.Stdin}
+}
+
+func (s *Stdin) Name() string {
+	return "stdin"
+}
+
+func (s *Stdin) Run(ctx context.Context, events chan<- Event) error {
+	return scanLines(ctx, s.reader, events, s.Name())
+}
+
+// scanLines emits every non-empty line read from r. Both "\n" and "\r" are
+// treated as line terminators since many scanners only send a carriage return.
+func scanLines(ctx context.Context, r io.Reader, events chan<- Event, source string) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(splitLines)
+
+	for scanner.Scan() {
+		barcode := strings.TrimSpace(scanner.Text())
+		if barcode == "" {
+			continue
+		}
+
+		if !emit(ctx, events, source, barcode) {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
+
+func splitLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	for i, b := range data {
+		if b == '\n' || b == '\r' {
+			return i + 1, data[:i], nil
+		}
+	}
+
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
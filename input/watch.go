@@ -0,0 +1,119 @@
+package input
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const watchInterval = 1 * time.Second
+
+// Watch polls a directory for dropped files. Every line of a file is treated
+// as a barcode and the file is removed once its barcodes have been sent.
+// Files are only read once their size and modification time stayed the same
+// for a poll, so files still being written aren't read half-way.
+type Watch struct {
+	dir string
+	// pending is the state of the files not read yet at the last poll
+	pending map[string]fileState
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+func NewWatch(dir string) *Watch {
+	return &Watch{dir: dir, pending: make(map[string]fileState)}
+}
+
+func (w *Watch) Name() string {
+	return "watch"
+}
+
+func (w *Watch) Run(ctx context.Context, events chan<- Event) error {
+	if info, err := os.Stat(w.dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("watch directory %s is not accessible", w.dir)
+	}
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.poll(ctx, events); err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (w *Watch) poll(ctx context.Context, events chan<- Event) error {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read watch directory: %w", err)
+	}
+
+	// Process files oldest first so scans keep their drop order
+	files := make([]os.FileInfo, 0, len(entries))
+	pending := make(map[string]fileState)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		state := fileState{size: info.Size(), modTime: info.ModTime()}
+		if previous, ok := w.pending[info.Name()]; !ok || previous != state {
+			pending[info.Name()] = state
+			continue
+		}
+		files = append(files, info)
+	}
+	w.pending = pending
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+
+	for _, file := range files {
+		path := filepath.Join(w.dir, file.Name())
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("⚠️  Failed to read %s: %v\n", path, err)
+			continue
+		}
+
+		lines := strings.Split(string(data), "\n")
+		for i, line := range lines {
+			barcode := strings.TrimSpace(line)
+			if barcode == "" {
+				continue
+			}
+			if !emit(ctx, events, w.Name(), barcode) {
+				// Keep the barcodes that weren't sent for the next run
+				if err := os.WriteFile(path, []byte(strings.Join(lines[i:], "\n")), file.Mode().Perm()); err != nil {
+					return fmt.Errorf("failed to keep unprocessed barcodes in %s: %w", path, err)
+				}
+				return nil
+			}
+		}
+
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove processed file %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
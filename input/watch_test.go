@@ -0,0 +1,90 @@
+package input
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// drain returns the barcodes of the events sent so far.
+func drain(events chan Event) []string {
+	var barcodes []string
+	for {
+		select {
+		case event := <-events:
+			barcodes = append(barcodes, event.Barcode)
+		default:
+			return barcodes
+		}
+	}
+}
+
+func TestWatchWaitsForFilesToSettle(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "scans.txt")
+	w := NewWatch(dir)
+	events := make(chan Event, 10)
+	ctx := context.Background()
+
+	poll := func() []string {
+		t.Helper()
+		if err := w.poll(ctx, events); err != nil {
+			t.Fatal(err)
+		}
+		return drain(events)
+	}
+
+	if err := os.WriteFile(path, []byte("5099902988023\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := poll(); len(got) != 0 {
+		t.Fatalf("read a new file right away: %v", got)
+	}
+
+	// The writer isn't done yet
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("0602547670342\n")
+	file.Close()
+	if got := poll(); len(got) != 0 {
+		t.Fatalf("read a file that changed since the last poll: %v", got)
+	}
+
+	got := poll()
+	if len(got) != 2 || got[0] != "5099902988023" || got[1] != "0602547670342" {
+		t.Fatalf("got %v, want both barcodes", got)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("file not removed after reading: %v", err)
+	}
+}
+
+func TestWatchKeepsUnsentBarcodes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "scans.txt")
+	if err := os.WriteFile(path, []byte("5099902988023\n0602547670342\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first poll only notes the file
+	w := NewWatch(dir)
+	w.poll(context.Background(), make(chan Event, 10))
+
+	// Nobody takes the events
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := w.poll(ctx, make(chan Event)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "5099902988023\n0602547670342\n" {
+		t.Errorf("file left with %q, want every barcode", data)
+	}
+}
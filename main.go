@@ -1,32 +1,46 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"strings"
 	"time"
 
+	"barcode-music-player/api"
 	"barcode-music-player/auth"
 	"barcode-music-player/config"
+	"barcode-music-player/input"
 	"barcode-music-player/musicbrainz"
+	"barcode-music-player/player"
 	"barcode-music-player/spotify"
 )
 
 var (
+	cfg               *config.Config
 	spotifyClient     *spotify.Client
 	musicbrainzClient *musicbrainz.Client
 )
 
 func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// runScanner scans barcodes interactively, logging in to Spotify in the
+// browser if needed.
+func runScanner(args []string) error {
+	f := newFlags("scan", "")
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+
 	fmt.Println("🎵 Barcode Music Player")
 	fmt.Println("=====================")
 
 	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		log.Fatal("Configuration error:", err)
+	if err := f.loadConfig(); err != nil {
+		return err
 	}
 
 	// Initialize clients
@@ -36,50 +50,101 @@ func main() {
 	// Authenticate with Spotify
 	fmt.Println("🔐 Authenticating with Spotify...")
 	if err := authenticateSpotify(); err != nil {
-		log.Fatal("Authentication failed:", err)
+		return fail(exitAuth, fmt.Errorf("authentication failed: %w", err))
 	}
 
 	fmt.Println("✅ Successfully authenticated with Spotify!")
+
+	// Logins from before syncing was configured lack its scopes
+	if missing := spotifyClient.MissingScopes(player.SyncScopes(cfg)...); len(missing) > 0 {
+		fmt.Printf("🔐 Syncing needs more Spotify permissions (%s), logging in again...\n", strings.Join(missing, ", "))
+		if err := loginSpotify(os.Stdout); err != nil {
+			return fail(exitAuth, fmt.Errorf("authentication failed: %w", err))
+		}
+	}
+
+	setMarket(os.Stdout)
+
+	p, err := player.New(cfg, spotifyClient, musicbrainzClient, os.Stdout)
+	if err != nil {
+		return fail(exitConfig, err)
+	}
+
 	fmt.Println()
 	fmt.Println("Ready to scan barcodes! 🎵")
-	fmt.Println("Scan a barcode to play the album on Spotify!")
+	if cfg.DryRun {
+		fmt.Println("🧪 Dry run: scans are resolved but nothing is played")
+	} else {
+		fmt.Println("Scan a barcode to play the album on Spotify!")
+	}
 	fmt.Println("Press Ctrl+C to exit")
 	fmt.Println()
 
-	scanner := bufio.NewScanner(os.Stdin)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	for {
-		fmt.Print("Scan barcode (or type 'quit' to exit): ")
+	if cfg.APIEnabled {
+		go runAPI(ctx, ctx, cfg, p)
+	}
 
-		if !scanner.Scan() {
-			break
-		}
+	sources := newInputs(cfg)
+	if len(sources) == 0 {
+		return fail(exitConfig, fmt.Errorf("no input sources enabled"))
+	}
 
-		barcode := strings.TrimSpace(scanner.Text())
+	prompt := func() {
+		if cfg.InputStdinEnabled {
+			fmt.Print("Scan barcode (or type 'quit' to exit): ")
+		}
+	}
 
-		if barcode == "quit" {
+	prompt()
+	for event := range input.NewMultiplexer(cfg.ScanDebounce, sources...).Run(ctx) {
+		if event.Source == "stdin" && event.Barcode == "quit" {
 			fmt.Println("Goodbye! 👋")
 			break
 		}
 
-		if barcode == "" {
-			continue
-		}
-
-		fmt.Printf("🔍 Processing barcode: %s\n", barcode)
-
-		if err := processBarcode(barcode); err != nil {
+		if _, err := p.Scan(ctx, event); err != nil {
 			fmt.Printf("❌ Error: %v\n", err)
 		}
 
 		fmt.Println()
+		prompt()
 	}
 
-	if err := scanner.Err(); err != nil {
-		log.Fatal(err)
+	return nil
+}
+
+func runAPI(ctx, scans context.Context, cfg *config.Config, p *player.Player) {
+	fmt.Printf("🌐 Control API listening on %s\n", cfg.APIAddr)
+	if err := api.NewServer(cfg.APIAddr, cfg.APIToken, p).Run(ctx, scans); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
 	}
 }
 
+func newInputs(cfg *config.Config) []input.Input {
+	var sources []input.Input
+
+	if cfg.InputStdinEnabled {
+		sources = append(sources, input.NewStdin())
+	}
+	if cfg.InputEvdevEnabled {
+		sources = append(sources, input.NewEvdev(cfg.InputEvdevDevice))
+	}
+	if cfg.InputSerialEnabled {
+		sources = append(sources, input.NewSerial(cfg.InputSerialDevice))
+	}
+	if cfg.InputHTTPEnabled {
+		sources = append(sources, input.NewHTTP(cfg.InputHTTPAddr, cfg.APIToken))
+	}
+	if cfg.InputWatchEnabled {
+		sources = append(sources, input.NewWatch(cfg.InputWatchDir))
+	}
+
+	return sources
+}
+
 func authenticateSpotify() error {
 	// First, try to load a stored token
 	if spotifyClient.LoadStoredToken() {
@@ -88,7 +153,12 @@ func authenticateSpotify() error {
 	}
 
 	fmt.Println("🔐 No stored token found, starting OAuth flow...")
+	return loginSpotify(os.Stdout)
+}
 
+// loginSpotify runs the OAuth flow in the browser and stores the token.
+// Progress messages go to out.
+func loginSpotify(out io.Writer) error {
 	// Create OAuth handler
 	oauthHandler := auth.NewOAuthHandler(spotifyClient.RedirectURI)
 
@@ -99,10 +169,10 @@ func authenticateSpotify() error {
 
 	// Get authorization URL and open browser
 	authURL := spotifyClient.GetAuthURL()
-	fmt.Printf("Opening browser for authorization: %s\n", authURL)
+	fmt.Fprintf(out, "Opening browser for authorization: %s\n", authURL)
 
 	if err := auth.OpenBrowser(authURL); err != nil {
-		fmt.Printf("Failed to open browser automatically. Please open this URL manually:\n%s\n", authURL)
+		fmt.Fprintf(out, "Failed to open browser automatically. Please open this URL manually:\n%s\n", authURL)
 	}
 
 	// Wait for authorization code
@@ -112,46 +182,9 @@ func authenticateSpotify() error {
 	}
 
 	// Exchange code for access token
-	if err := spotifyClient.ExchangeCodeForToken(code); err != nil {
+	if err := spotifyClient.ExchangeCodeForToken(context.Background(), code); err != nil {
 		return fmt.Errorf("failed to exchange code for token: %w", err)
 	}
 
 	return nil
 }
-
-func processBarcode(barcode string) error {
-	// Step 1: Look up album in MusicBrainz
-	fmt.Println("🔍 Looking up album in MusicBrainz...")
-	release, err := musicbrainzClient.SearchByBarcode(barcode)
-	if err != nil {
-		return fmt.Errorf("failed to find album for barcode %s: %w", barcode, err)
-	}
-
-	fmt.Printf("📀 Found album: \"%s\" by %s\n", release.Title, release.GetMainArtist())
-
-	// Step 2: Search for album on Spotify
-	fmt.Println("🎵 Searching for album on Spotify...")
-	searchQuery := release.GetSearchQuery()
-	fmt.Printf("🔍 Search query: %s\n", searchQuery)
-	albums, err := spotifyClient.SearchAlbums(searchQuery)
-	if err != nil {
-		return fmt.Errorf("failed to search Spotify: %w", err)
-	}
-
-	if len(albums) == 0 {
-		return fmt.Errorf("no albums found on Spotify for: %s", searchQuery)
-	}
-
-	// Use the first (most relevant) result
-	album := albums[0]
-	fmt.Printf("🎯 Found on Spotify: \"%s\" by %s\n", album.Name, album.GetMainArtist())
-
-	// Step 3: Play the album
-	fmt.Println("▶️  Playing album...")
-	if err := spotifyClient.PlayAlbum(album.URI); err != nil {
-		return fmt.Errorf("failed to play album: %w", err)
-	}
-
-	fmt.Printf("🎉 Successfully playing: \"%s\" by %s\n", album.Name, album.GetMainArtist())
-	return nil
-}
@@ -0,0 +1,204 @@
+package mappings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"barcode-music-player/spotify"
+)
+
+// Mapping pins a barcode (or disc ID) to an album and playback options.
+// Zero values keep the configured defaults.
+type Mapping struct {
+	Barcode string `json:"barcode"`
+	// AlbumURI skips the MusicBrainz and Spotify lookup when set
+	AlbumURI string `json:"album_uri,omitempty"`
+	Note     string `json:"note,omitempty"`
+
+	Shuffle *bool  `json:"shuffle,omitempty"`
+	Repeat  string `json:"repeat,omitempty"`
+	// StartTrack is the 1-based track to start at
+	StartTrack    int    `json:"start_track,omitempty"`
+	StartTrackURI string `json:"start_track_uri,omitempty"`
+	PositionMS    int    `json:"position_ms,omitempty"`
+	Volume        *int   `json:"volume,omitempty"`
+	Device        string `json:"device,omitempty"`
+}
+
+// Store is a JSON file of mappings keyed by barcode.
+type Store struct {
+	path string
+
+	mu       sync.Mutex
+	mappings map[string]Mapping
+}
+
+// DefaultPath returns the mappings file next to the stored Spotify token.
+func DefaultPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".barcode-music-player-mappings.json")
+}
+
+// Open loads the mappings file at path. A missing file is an empty store.
+func Open(path string) (*Store, error) {
+	if path == "" {
+		path = DefaultPath()
+	}
+
+	s := &Store{
+		path:     path,
+		mappings: make(map[string]Mapping),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mappings: %w", err)
+	}
+
+	var list []Mapping
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse mappings %s: %w", path, err)
+	}
+
+	for _, m := range list {
+		if err := m.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid mapping in %s: %w", path, err)
+		}
+		s.mappings[m.Barcode] = m
+	}
+
+	return s, nil
+}
+
+// Path returns the file the store is saved to.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Get returns the mapping for a barcode.
+func (s *Store) Get(barcode string) (Mapping, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.mappings[barcode]
+	return m, ok
+}
+
+// List returns all mappings sorted by barcode.
+func (s *Store) List() []Mapping {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sorted()
+}
+
+// Set adds or replaces a mapping and saves the store.
+func (s *Store) Set(m Mapping) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mappings[m.Barcode] = m
+	return s.save()
+}
+
+// Delete removes a mapping and saves the store.
+func (s *Store) Delete(barcode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.mappings[barcode]; !ok {
+		return fmt.Errorf("no mapping for barcode %s", barcode)
+	}
+
+	delete(s.mappings, barcode)
+	return s.save()
+}
+
+func (s *Store) sorted() []Mapping {
+	list := make([]Mapping, 0, len(s.mappings))
+	for _, m := range s.mappings {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Barcode < list[j].Barcode
+	})
+	return list
+}
+
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.path, data, 0600)
+}
+
+// Validate checks a mapping's playback options.
+func (m Mapping) Validate() error {
+	if m.Barcode == "" {
+		return fmt.Errorf("mapping without barcode")
+	}
+
+	if _, ok := spotify.ParseAlbumLink(m.AlbumURI); m.AlbumURI != "" && !ok {
+		return fmt.Errorf("barcode %s: %q is not a Spotify album", m.Barcode, m.AlbumURI)
+	}
+
+	switch m.Repeat {
+	case "", spotify.RepeatOff, spotify.RepeatContext, spotify.RepeatTrack:
+	default:
+		return fmt.Errorf("barcode %s: repeat must be %s, %s or %s", m.Barcode, spotify.RepeatOff, spotify.RepeatContext, spotify.RepeatTrack)
+	}
+
+	if m.Volume != nil && (*m.Volume < 0 || *m.Volume > 100) {
+		return fmt.Errorf("barcode %s: volume must be between 0 and 100", m.Barcode)
+	}
+
+	if m.StartTrack < 0 || m.PositionMS < 0 {
+		return fmt.Errorf("barcode %s: start track and position must not be negative", m.Barcode)
+	}
+
+	return nil
+}
+
+// HasStart reports whether the mapping picks the track playback starts at.
+func (m Mapping) HasStart() bool {
+	return m.StartTrack > 0 || m.StartTrackURI != ""
+}
+
+// Apply overrides the options the mapping sets.
+func (m Mapping) Apply(opts spotify.PlayOptions) spotify.PlayOptions {
+	if m.Shuffle != nil {
+		opts.Shuffle = *m.Shuffle
+	}
+	if m.Repeat != "" {
+		opts.Repeat = m.Repeat
+	}
+	if m.StartTrack > 0 {
+		opts.Position = m.StartTrack - 1
+	}
+	if m.StartTrackURI != "" {
+		opts.TrackURI = m.StartTrackURI
+	}
+	if m.PositionMS > 0 {
+		opts.PositionMS = m.PositionMS
+	}
+	if m.Volume != nil {
+		opts.Volume = m.Volume
+	}
+	if m.Device != "" {
+		opts.Device = m.Device
+	}
+	return opts
+}
@@ -0,0 +1,126 @@
+package mappings
+
+import (
+	"reflect"
+	"testing"
+
+	"barcode-music-player/spotify"
+)
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping Mapping
+		wantErr bool
+	}{
+		{"barcode only", Mapping{Barcode: "5099902987729"}, false},
+		{"no barcode", Mapping{AlbumURI: "spotify:album:1weenld61qoidwYuZ1GESA"}, true},
+		{"album URI", Mapping{Barcode: "1", AlbumURI: "spotify:album:1weenld61qoidwYuZ1GESA"}, false},
+		{"album link", Mapping{Barcode: "1", AlbumURI: "https://open.spotify.com/intl-de/album/1weenld61qoidwYuZ1GESA?si=x"}, false},
+		{"track URI", Mapping{Barcode: "1", AlbumURI: "spotify:track:4u7EnebtmKWzUH433cf5Qv"}, true},
+		{"empty album URI ID", Mapping{Barcode: "1", AlbumURI: "spotify:album:"}, true},
+		{"other site", Mapping{Barcode: "1", AlbumURI: "https://example.com/album/1weenld61qoidwYuZ1GESA"}, true},
+		{"repeat off", Mapping{Barcode: "1", Repeat: spotify.RepeatOff}, false},
+		{"repeat context", Mapping{Barcode: "1", Repeat: spotify.RepeatContext}, false},
+		{"repeat track", Mapping{Barcode: "1", Repeat: spotify.RepeatTrack}, false},
+		{"unknown repeat", Mapping{Barcode: "1", Repeat: "forever"}, true},
+		{"volume 0", Mapping{Barcode: "1", Volume: intPtr(0)}, false},
+		{"volume 100", Mapping{Barcode: "1", Volume: intPtr(100)}, false},
+		{"volume below 0", Mapping{Barcode: "1", Volume: intPtr(-1)}, true},
+		{"volume above 100", Mapping{Barcode: "1", Volume: intPtr(101)}, true},
+		{"start track", Mapping{Barcode: "1", StartTrack: 3, PositionMS: 1500}, false},
+		{"negative start track", Mapping{Barcode: "1", StartTrack: -1}, true},
+		{"negative position", Mapping{Barcode: "1", PositionMS: -1}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.mapping.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	defaults := spotify.PlayOptions{
+		Shuffle: true,
+		Repeat:  spotify.RepeatContext,
+		Volume:  intPtr(40),
+		Device:  "Kitchen",
+	}
+
+	tests := []struct {
+		name    string
+		mapping Mapping
+		want    spotify.PlayOptions
+	}{
+		{
+			name:    "empty mapping keeps the defaults",
+			mapping: Mapping{Barcode: "1"},
+			want:    defaults,
+		},
+		{
+			name:    "shuffle off overrides",
+			mapping: Mapping{Barcode: "1", Shuffle: boolPtr(false)},
+			want:    spotify.PlayOptions{Repeat: spotify.RepeatContext, Volume: intPtr(40), Device: "Kitchen"},
+		},
+		{
+			name:    "start track is zero-based",
+			mapping: Mapping{Barcode: "1", StartTrack: 3, PositionMS: 90000},
+			want:    spotify.PlayOptions{Shuffle: true, Repeat: spotify.RepeatContext, Position: 2, PositionMS: 90000, Volume: intPtr(40), Device: "Kitchen"},
+		},
+		{
+			name: "everything overridden",
+			mapping: Mapping{
+				Barcode:       "1",
+				Shuffle:       boolPtr(false),
+				Repeat:        spotify.RepeatTrack,
+				StartTrackURI: "spotify:track:4u7EnebtmKWzUH433cf5Qv",
+				Volume:        intPtr(0),
+				Device:        "Living Room",
+			},
+			want: spotify.PlayOptions{
+				Repeat:   spotify.RepeatTrack,
+				TrackURI: "spotify:track:4u7EnebtmKWzUH433cf5Qv",
+				Volume:   intPtr(0),
+				Device:   "Living Room",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.mapping.Apply(defaults); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Apply = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	if *defaults.Volume != 40 {
+		t.Errorf("Apply changed the default volume to %d", *defaults.Volume)
+	}
+}
+
+func TestHasStart(t *testing.T) {
+	tests := []struct {
+		mapping Mapping
+		want    bool
+	}{
+		{Mapping{}, false},
+		{Mapping{PositionMS: 1000}, false},
+		{Mapping{StartTrack: 1}, true},
+		{Mapping{StartTrackURI: "spotify:track:4u7EnebtmKWzUH433cf5Qv"}, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.mapping.HasStart(); got != tt.want {
+			t.Errorf("HasStart(%+v) = %v, want %v", tt.mapping, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package match
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Edition preference values, as used in EDITION_PREFERENCE.
+const (
+	PreferOriginal = "original"
+	PreferRemaster = "remaster"
+	PreferExplicit = "explicit"
+	PreferClean    = "clean"
+	PreferStandard = "standard"
+	PreferDeluxe   = "deluxe"
+	MatchTracks    = "match-tracks"
+)
+
+// Preference decides between otherwise equally good Spotify editions of an
+// album. Empty fields mean no preference.
+type Preference struct {
+	Remaster        string
+	Content         string
+	Edition         string
+	MatchTrackCount bool
+}
+
+// ParsePreference parses a comma-separated list of preference values, e.g.
+// "original,clean,standard,match-tracks".
+func ParsePreference(value string) (Preference, error) {
+	var pref Preference
+
+	for _, token := range strings.Split(value, ",") {
+		switch token = strings.TrimSpace(token); token {
+		case "":
+		case PreferOriginal, PreferRemaster:
+			pref.Remaster = token
+		case PreferExplicit, PreferClean:
+			pref.Content = token
+		case PreferStandard, PreferDeluxe:
+			pref.Edition = token
+		case MatchTracks:
+			pref.MatchTrackCount = true
+		default:
+			return pref, fmt.Errorf("unknown edition preference %q", token)
+		}
+	}
+
+	return pref, nil
+}
+
+// NeedsTracks reports whether ranking needs the candidates' tracklists, which
+// search results don't include.
+func (p Preference) NeedsTracks() bool {
+	return p.Content != ""
+}
@@ -0,0 +1,255 @@
+package match
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"barcode-music-player/musicbrainz"
+	"barcode-music-player/normalize"
+	"barcode-music-player/spotify"
+)
+
+// Candidate is a Spotify album scored against a MusicBrainz release.
+type Candidate struct {
+	Album   spotify.Album `json:"album"`
+	Score   float64       `json:"score"`
+	Reasons []string      `json:"reasons"`
+}
+
+func (c *Candidate) add(points float64, reason string, args ...interface{}) {
+	c.Score += points
+	c.Reasons = append(c.Reasons, fmt.Sprintf("%+.0f "+reason, append([]interface{}{points}, args...)...))
+}
+
+// CloseScore is how far below the best candidate a candidate still scores
+// almost as well.
+const CloseScore = 10
+
+// trackLengthTolerance is how much a Spotify track's length may differ from
+// the MusicBrainz track's to count as the same.
+const trackLengthTolerance = 3 * time.Second
+
+// ConfidentScore is the score of an album whose title and main artist both
+// match the release. Searching further rarely finds a better one.
+const ConfidentScore = 80
+
+// Rank scores albums against the release and returns them best first. Albums
+// with equal scores keep their search order. Alternate names, if any, count
+// as matches for the release's title and artist.
+func Rank(release *musicbrainz.Release, alternates *musicbrainz.AlternateNames, albums []spotify.Album, pref Preference) []Candidate {
+	titles, artists := names(release, alternates)
+
+	candidates := make([]Candidate, len(albums))
+	for i, album := range albums {
+		candidates[i] = score(release, titles, artists, album, pref)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	return candidates
+}
+
+// Close returns the albums of the candidates, best first, that score within
+// CloseScore of the best one.
+func Close(candidates []Candidate) []spotify.Album {
+	var albums []spotify.Album
+	for _, candidate := range candidates {
+		if candidates[0].Score-candidate.Score > CloseScore {
+			break
+		}
+		albums = append(albums, candidate.Album)
+	}
+	return albums
+}
+
+// Confident reports whether an album scores at least ConfidentScore against
+// the release.
+func Confident(release *musicbrainz.Release, alternates *musicbrainz.AlternateNames, album spotify.Album, pref Preference) bool {
+	titles, artists := names(release, alternates)
+	return score(release, titles, artists, album, pref).Score >= ConfidentScore
+}
+
+// names returns the normalized titles and artists that count as the
+// release's.
+func names(release *musicbrainz.Release, alternates *musicbrainz.AlternateNames) (titles, artists []string) {
+	titles = []string{normalize.Key(release.Title)}
+	artists = []string{normalize.Key(release.GetMainArtist())}
+	if alternates != nil {
+		for _, title := range alternates.Titles {
+			titles = append(titles, normalize.Key(title))
+		}
+		for _, artist := range alternates.Artists {
+			artists = append(artists, normalize.Key(artist))
+		}
+	}
+	return titles, artists
+}
+
+func score(release *musicbrainz.Release, titles, artists []string, album spotify.Album, pref Preference) Candidate {
+	candidate := Candidate{Album: album}
+
+	// Title
+	albumTitle := normalize.Key(album.Name)
+	bestSimilarity := 0.0
+	for _, title := range titles {
+		bestSimilarity = max(bestSimilarity, wordSimilarity(title, albumTitle))
+	}
+	if bestSimilarity == 1 {
+		candidate.add(50, "title matches")
+	} else if bestSimilarity > 0 {
+		candidate.add(40*bestSimilarity, "title %.0f%% similar", bestSimilarity*100)
+	}
+
+	// Artist
+	for i, artist := range album.Artists {
+		if !contains(artists, normalize.Key(artist.Name)) {
+			continue
+		}
+		if i == 0 {
+			candidate.add(30, "main artist matches")
+		} else {
+			candidate.add(15, "artist credited")
+		}
+		break
+	}
+
+	// Release type
+	if release.ReleaseGroup.Type != "" && strings.EqualFold(release.ReleaseGroup.Type, album.AlbumType) {
+		candidate.add(5, "same release type (%s)", album.AlbumType)
+	}
+
+	releaseEdition := normalize.DetectEdition(release.Title)
+	albumEdition := normalize.DetectEdition(album.Name)
+
+	// Original vs. remaster: follow the scanned release if it says, the preference otherwise
+	switch {
+	case releaseEdition.Remaster:
+		if albumEdition.Remaster {
+			candidate.add(10, "remaster like the release")
+		}
+	case pref.Remaster == PreferOriginal && albumEdition.Remaster:
+		candidate.add(-8, "remaster, original preferred")
+	case pref.Remaster == PreferRemaster && albumEdition.Remaster:
+		candidate.add(8, "remaster preferred")
+	}
+
+	// Standard vs. deluxe
+	switch {
+	case releaseEdition.Deluxe:
+		if albumEdition.Deluxe {
+			candidate.add(10, "deluxe like the release")
+		}
+	case pref.Edition == PreferStandard && albumEdition.Deluxe:
+		candidate.add(-8, "deluxe, standard preferred")
+	case pref.Edition == PreferDeluxe && albumEdition.Deluxe:
+		candidate.add(8, "deluxe preferred")
+	}
+
+	// Explicit vs. clean
+	tracksLoaded := len(album.Tracks.Items) > 0
+	explicit := albumEdition.Explicit || album.HasExplicitTracks()
+	clean := albumEdition.Clean || (tracksLoaded && !explicit)
+	switch {
+	case pref.Content == PreferExplicit && explicit:
+		candidate.add(8, "explicit preferred")
+	case pref.Content == PreferExplicit && albumEdition.Clean:
+		candidate.add(-8, "clean, explicit preferred")
+	case pref.Content == PreferClean && clean:
+		candidate.add(8, "clean preferred")
+	case pref.Content == PreferClean && explicit:
+		candidate.add(-8, "explicit, clean preferred")
+	}
+
+	// Tracklist, if both are known: the release's track lengths
+	if similarity, ok := tracklistSimilarity(release.Tracks(0), album); ok {
+		candidate.add(15*similarity, "%.0f%% of track lengths match", similarity*100)
+	}
+
+	// Track count
+	if expected := release.TrackCount(); pref.MatchTrackCount && expected > 0 && album.TotalTracks > 0 {
+		if diff := abs(expected - album.TotalTracks); diff == 0 {
+			candidate.add(15, "track count matches (%d)", expected)
+		} else {
+			candidate.add(-float64(min(diff, 10)), "%d tracks instead of %d", album.TotalTracks, expected)
+		}
+	}
+
+	return candidate
+}
+
+// tracklistSimilarity returns the share of tracks whose lengths match, in
+// order, and whether both tracklists are known. Spotify albums only list
+// their tracks when fetched in full.
+func tracklistSimilarity(tracks []musicbrainz.Track, album spotify.Album) (float64, bool) {
+	known := false
+	for _, track := range tracks {
+		known = known || track.Length > 0
+	}
+	if !known || len(album.Tracks.Items) == 0 {
+		return 0, false
+	}
+
+	matched := 0
+	for i, track := range tracks {
+		if i == len(album.Tracks.Items) {
+			break
+		}
+		length := time.Duration(album.Tracks.Items[i].DurationMS) * time.Millisecond
+		if track.Length > 0 && (track.Duration()-length).Abs() <= trackLengthTolerance {
+			matched++
+		}
+	}
+
+	return float64(matched) / float64(max(len(tracks), album.TotalTracks, len(album.Tracks.Items))), true
+}
+
+// wordSimilarity returns the Jaccard similarity of the words of a and b.
+func wordSimilarity(a, b string) float64 {
+	wordsA := strings.Fields(a)
+	wordsB := strings.Fields(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	set := make(map[string]bool, len(wordsA))
+	for _, word := range wordsA {
+		set[word] = true
+	}
+
+	shared := 0
+	union := len(set)
+	seen := make(map[string]bool, len(wordsB))
+	for _, word := range wordsB {
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		if set[word] {
+			shared++
+		} else {
+			union++
+		}
+	}
+
+	return float64(shared) / float64(union)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,256 @@
+package match
+
+import (
+	"encoding/json"
+	"testing"
+
+	"barcode-music-player/musicbrainz"
+	"barcode-music-player/spotify"
+)
+
+func release(title, artist, releaseType string, tracks int) *musicbrainz.Release {
+	return &musicbrainz.Release{
+		Title:        title,
+		Artists:      []musicbrainz.Artist{{Name: artist}},
+		ReleaseGroup: musicbrainz.ReleaseGroup{Type: releaseType},
+		Media:        []musicbrainz.Medium{{TrackCount: tracks}},
+	}
+}
+
+// album builds a search result. The artists are unmarshaled since the
+// Album's artists are an anonymous struct.
+func album(t *testing.T, id, name, albumType string, tracks int, artists ...string) spotify.Album {
+	t.Helper()
+
+	var a spotify.Album
+	var credits []map[string]string
+	for _, artist := range artists {
+		credits = append(credits, map[string]string{"name": artist})
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"id":           id,
+		"name":         name,
+		"album_type":   albumType,
+		"total_tracks": tracks,
+		"artists":      credits,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &a); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func ids(candidates []Candidate) []string {
+	var ids []string
+	for _, c := range candidates {
+		ids = append(ids, c.Album.ID)
+	}
+	return ids
+}
+
+func TestRank(t *testing.T) {
+	abbeyRoad := release("Abbey Road", "The Beatles", "Album", 17)
+
+	tests := []struct {
+		name    string
+		release *musicbrainz.Release
+		albums  []spotify.Album
+		pref    string
+		want    []string
+	}{
+		{
+			name:    "title and artist beat a cover",
+			release: abbeyRoad,
+			albums: []spotify.Album{
+				album(t, "tribute", "Abbey Road Revisited", "album", 17, "Various Artists"),
+				album(t, "original", "Abbey Road", "album", 17, "The Beatles"),
+			},
+			want: []string{"original", "tribute"},
+		},
+		{
+			name:    "equal scores keep the search order",
+			release: abbeyRoad,
+			albums: []spotify.Album{
+				album(t, "first", "Abbey Road", "album", 17, "The Beatles"),
+				album(t, "second", "Abbey Road", "album", 17, "The Beatles"),
+			},
+			want: []string{"first", "second"},
+		},
+		{
+			name:    "original preferred",
+			release: abbeyRoad,
+			albums: []spotify.Album{
+				album(t, "remaster", "Abbey Road (Remastered)", "album", 17, "The Beatles"),
+				album(t, "original", "Abbey Road", "album", 17, "The Beatles"),
+			},
+			pref: "original",
+			want: []string{"original", "remaster"},
+		},
+		{
+			name:    "remaster preferred",
+			release: abbeyRoad,
+			albums: []spotify.Album{
+				album(t, "original", "Abbey Road", "album", 17, "The Beatles"),
+				album(t, "remaster", "Abbey Road (Remastered)", "album", 17, "The Beatles"),
+			},
+			pref: "remaster",
+			want: []string{"remaster", "original"},
+		},
+		{
+			name:    "a remastered release overrides the preference",
+			release: release("Abbey Road (Remastered)", "The Beatles", "Album", 17),
+			albums: []spotify.Album{
+				album(t, "original", "Abbey Road", "album", 17, "The Beatles"),
+				album(t, "remaster", "Abbey Road - Remastered 2009", "album", 17, "The Beatles"),
+			},
+			pref: "original",
+			want: []string{"remaster", "original"},
+		},
+		{
+			name:    "deluxe preferred",
+			release: abbeyRoad,
+			albums: []spotify.Album{
+				album(t, "standard", "Abbey Road", "album", 17, "The Beatles"),
+				album(t, "deluxe", "Abbey Road (Super Deluxe Edition)", "album", 40, "The Beatles"),
+			},
+			pref: "deluxe",
+			want: []string{"deluxe", "standard"},
+		},
+		{
+			name:    "track count",
+			release: abbeyRoad,
+			albums: []spotify.Album{
+				album(t, "longer", "Abbey Road", "album", 40, "The Beatles"),
+				album(t, "same", "Abbey Road", "album", 17, "The Beatles"),
+			},
+			pref: "match-tracks",
+			want: []string{"same", "longer"},
+		},
+		{
+			name:    "credited artist",
+			release: release("Watch the Throne", "JAY-Z", "Album", 12),
+			albums: []spotify.Album{
+				album(t, "other", "Watch the Throne", "album", 12, "Someone Else"),
+				album(t, "credited", "Watch the Throne", "album", 12, "Kanye West", "JAY-Z"),
+			},
+			want: []string{"credited", "other"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pref, err := ParsePreference(tt.pref)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := ids(Rank(tt.release, nil, tt.albums, pref))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRankAlternates(t *testing.T) {
+	r := release("Смысловые галлюцинации", "Кино", "Album", 10)
+	albums := []spotify.Album{
+		album(t, "other", "Gruppa krovi", "album", 10, "Kino"),
+		album(t, "alias", "Smyslovye gallyutsinatsii", "album", 10, "Kino"),
+	}
+	alternates := &musicbrainz.AlternateNames{
+		Titles:  []string{"Smyslovye gallyutsinatsii"},
+		Artists: []string{"Kino"},
+	}
+
+	candidates := Rank(r, alternates, albums, Preference{})
+	if got := candidates[0].Album.ID; got != "alias" {
+		t.Errorf("best candidate %s, want alias", got)
+	}
+	if !Confident(r, alternates, albums[1], Preference{}) {
+		t.Errorf("not confident about %q with alternate names", albums[1].Name)
+	}
+	if Confident(r, nil, albums[1], Preference{}) {
+		t.Errorf("confident about %q without alternate names", albums[1].Name)
+	}
+}
+
+func TestConfident(t *testing.T) {
+	r := release("Abbey Road", "The Beatles", "Album", 17)
+
+	tests := []struct {
+		album spotify.Album
+		want  bool
+	}{
+		{album(t, "1", "Abbey Road", "album", 17, "The Beatles"), true},
+		{album(t, "2", "Abbey Road (Remastered)", "album", 17, "The Beatles"), true},
+		{album(t, "3", "Abbey Road", "album", 17, "Various Artists"), false},
+		{album(t, "4", "Let It Be", "album", 12, "The Beatles"), false},
+	}
+
+	for _, tt := range tests {
+		if got := Confident(r, nil, tt.album, Preference{}); got != tt.want {
+			t.Errorf("Confident(%q by %s) = %v, want %v", tt.album.Name, tt.album.Artists[0].Name, got, tt.want)
+		}
+	}
+}
+
+func TestParsePreference(t *testing.T) {
+	pref, err := ParsePreference(" original, clean,deluxe ,match-tracks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Preference{Remaster: PreferOriginal, Content: PreferClean, Edition: PreferDeluxe, MatchTrackCount: true}
+	if pref != want {
+		t.Errorf("got %+v, want %+v", pref, want)
+	}
+
+	if _, err := ParsePreference("original,newest"); err == nil {
+		t.Error("no error for an unknown preference")
+	}
+}
+
+func TestRankTrackLengths(t *testing.T) {
+	r := release("Abbey Road", "The Beatles", "Album", 3)
+	r.Media[0].Tracks = []musicbrainz.Track{
+		{Title: "Come Together", Length: 259000},
+		{Title: "Something", Length: 182000},
+		{Title: "Maxwell's Silver Hammer", Length: 207000},
+	}
+
+	withTracks := func(a spotify.Album, lengths ...int) spotify.Album {
+		for _, length := range lengths {
+			a.Tracks.Items = append(a.Tracks.Items, spotify.Track{DurationMS: length})
+		}
+		return a
+	}
+	albums := []spotify.Album{
+		withTracks(album(t, "mix", "Abbey Road", "album", 3, "The Beatles"), 260000, 203000, 210000),
+		withTracks(album(t, "original", "Abbey Road", "album", 3, "The Beatles"), 259500, 181000, 208000),
+	}
+
+	candidates := Rank(r, nil, albums, Preference{})
+	if got := ids(candidates); got[0] != "original" {
+		t.Errorf("got %v, want original first", got)
+	}
+	if diff := candidates[0].Score - candidates[1].Score; diff <= 0 || diff > CloseScore {
+		t.Errorf("scores %v and %v, want them close", candidates[0].Score, candidates[1].Score)
+	}
+
+	// Without the Spotify tracklists, the albums tie
+	if got := Close(Rank(r, nil, []spotify.Album{
+		album(t, "mix", "Abbey Road", "album", 3, "The Beatles"),
+		album(t, "original", "Abbey Road", "album", 3, "The Beatles"),
+		album(t, "live", "Live at the BBC", "album", 69, "The Beatles"),
+	}, Preference{})); len(got) != 2 {
+		t.Errorf("got %d close albums, want 2", len(got))
+	}
+}
@@ -0,0 +1,154 @@
+package musicbrainz
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"barcode-music-player/normalize"
+)
+
+type Alias struct {
+	Name     string `json:"name"`
+	SortName string `json:"sort-name"`
+	Locale   string `json:"locale"`
+	Type     string `json:"type"`
+	Primary  bool   `json:"primary"`
+}
+
+type ArtistDetails struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	SortName string  `json:"sort-name"`
+	Aliases  []Alias `json:"aliases"`
+}
+
+// AlternateNames are Latin-script titles and artist names for a release whose
+// own title or artist is in another script.
+type AlternateNames struct {
+	Titles  []string
+	Artists []string
+}
+
+func (a *AlternateNames) addTitle(title string) {
+	a.Titles = appendUnique(a.Titles, title)
+}
+
+func (a *AlternateNames) addArtist(name string) {
+	a.Artists = appendUnique(a.Artists, name)
+}
+
+func appendUnique(names []string, name string) []string {
+	if name == "" || !normalize.IsLatin(name) {
+		return names
+	}
+	for _, existing := range names {
+		if normalize.Key(existing) == normalize.Key(name) {
+			return names
+		}
+	}
+	return append(names, name)
+}
+
+func (c *Client) GetArtist(ctx context.Context, mbid string, inc ...string) (*ArtistDetails, error) {
+	params := url.Values{}
+	if len(inc) > 0 {
+		params.Add("inc", strings.Join(inc, "+"))
+	}
+
+	var artist ArtistDetails
+	if err := c.get(ctx, "artist/"+url.PathEscape(mbid), params, &artist); err != nil {
+		return nil, fmt.Errorf("failed to get artist %s: %w", mbid, err)
+	}
+
+	return &artist, nil
+}
+
+// NeedsAlternateNames reports whether the release's title or main artist is
+// written in a non-Latin script, which Spotify search often can't match.
+func (r *Release) NeedsAlternateNames() bool {
+	return !normalize.IsLatin(r.Title) || !normalize.IsLatin(r.GetMainArtist())
+}
+
+// GetAlternateNames finds Latin-script names for a release: the artist's
+// aliases and sort name, and the titles of transliterated releases in the
+// same release group.
+func (c *Client) GetAlternateNames(ctx context.Context, release *Release) (*AlternateNames, error) {
+	alternates := &AlternateNames{}
+
+	if len(release.Artists) > 0 && release.Artists[0].Details.ID != "" && !normalize.IsLatin(release.GetMainArtist()) {
+		artist, err := c.GetArtist(ctx, release.Artists[0].Details.ID, IncAliases)
+		if err != nil {
+			return nil, err
+		}
+
+		// Primary aliases first, they are the preferred name for their locale
+		for _, primary := range []bool{true, false} {
+			for _, alias := range artist.Aliases {
+				if alias.Primary == primary {
+					alternates.addArtist(alias.Name)
+				}
+			}
+		}
+		alternates.addArtist(sortNameToName(artist.SortName))
+	}
+
+	if release.ReleaseGroup.ID != "" && !normalize.IsLatin(release.Title) {
+		params := url.Values{}
+		params.Add("release-group", release.ReleaseGroup.ID)
+		params.Add("limit", "100")
+
+		var browseResp SearchResponse
+		if err := c.get(ctx, "release", params, &browseResp); err != nil {
+			return nil, fmt.Errorf("failed to get releases of release group: %w", err)
+		}
+
+		for _, other := range browseResp.Releases {
+			if other.TextRepresentation.Script == "Latn" {
+				alternates.addTitle(other.Title)
+			}
+		}
+	}
+
+	return alternates, nil
+}
+
+// sortNameToName turns a "Last, First" sort name into "First Last".
+func sortNameToName(sortName string) string {
+	if last, first, ok := strings.Cut(sortName, ", "); ok {
+		return first + " " + last
+	}
+	return sortName
+}
+
+// maxSearchNames caps how many titles and artist names are searched for, since
+// every combination costs a Spotify request.
+const maxSearchNames = 3
+
+// SearchNames returns the cleaned titles and artist names to search for, the
+// release's own first, followed by alternate names.
+func (r *Release) SearchNames(alternates *AlternateNames) (titles, artists []string) {
+	titles = []string{normalize.CleanTitle(r.Title)}
+	artists = []string{normalize.Fold(r.GetMainArtist())}
+
+	if alternates != nil {
+		for _, title := range alternates.Titles {
+			titles = appendName(titles, normalize.CleanTitle(title))
+		}
+		for _, artist := range alternates.Artists {
+			artists = appendName(artists, normalize.Fold(artist))
+		}
+	}
+
+	return titles[:min(len(titles), maxSearchNames)], artists[:min(len(artists), maxSearchNames)]
+}
+
+func appendName(names []string, name string) []string {
+	for _, existing := range names {
+		if normalize.Key(existing) == normalize.Key(name) {
+			return names
+		}
+	}
+	return append(names, name)
+}
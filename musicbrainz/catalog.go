@@ -0,0 +1,67 @@
+package musicbrainz
+
+import (
+	"net/url"
+	"strings"
+)
+
+// CoverArtArchive tells which images the Cover Art Archive has for a
+// release. It is included in release lookups, not in search results.
+type CoverArtArchive struct {
+	Artwork bool `json:"artwork"`
+	Front   bool `json:"front"`
+	Back    bool `json:"back"`
+	Count   int  `json:"count"`
+}
+
+// CoverURL returns the front cover from the Cover Art Archive, or "" if the
+// release has none.
+func (r *Release) CoverURL() string {
+	if !r.CoverArtArchive.Front {
+		return ""
+	}
+	return "https://coverartarchive.org/release/" + r.ID + "/front-500"
+}
+
+// Label returns the name of the first label the release was issued on.
+func (r *Release) Label() string {
+	for _, info := range r.LabelInfo {
+		if info.Label != nil && info.Label.Name != "" {
+			return info.Label.Name
+		}
+	}
+	return ""
+}
+
+// CatalogNumber returns the first catalog number of the release.
+func (r *Release) CatalogNumber() string {
+	for _, info := range r.LabelInfo {
+		if info.CatalogNumber != "" {
+			return info.CatalogNumber
+		}
+	}
+	return ""
+}
+
+// DiscogsID returns the ID of the Discogs release MusicBrainz links to, or ""
+// if there is none. Relations are only included with IncURLRels.
+func (r *Release) DiscogsID() string {
+	for _, relation := range r.Relations {
+		if relation.URL == nil {
+			continue
+		}
+
+		parsed, err := url.Parse(relation.URL.Resource)
+		if err != nil || strings.TrimPrefix(strings.ToLower(parsed.Host), "www.") != "discogs.com" {
+			continue
+		}
+
+		// https://www.discogs.com/release/1234567 or .../release/1234567-Title
+		path := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+		if len(path) == 2 && path[0] == "release" {
+			id, _, _ := strings.Cut(path[1], "-")
+			return id
+		}
+	}
+	return ""
+}
@@ -1,39 +1,112 @@
 package musicbrainz
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"barcode-music-player/normalize"
 )
 
+// ErrNotFound is returned when MusicBrainz has no release for a barcode or
+// disc ID, or the requested entity doesn't exist.
+var ErrNotFound = errors.New("not found in MusicBrainz")
+
+// minRequestInterval keeps us within MusicBrainz's limit of one request per
+// second.
+const minRequestInterval = 1 * time.Second
+
+// maxUnavailableRetries is how often a request is retried when MusicBrainz
+// answers 503, which it does when rate limiting.
+const maxUnavailableRetries = 3
+
 type Client struct {
 	BaseURL    string
 	HTTPClient *http.Client
+
+	mu          sync.Mutex
+	lastRequest time.Time
+}
+
+type URL struct {
+	ID       string `json:"id"`
+	Resource string `json:"resource"`
+}
+
+type Relation struct {
+	Type       string `json:"type"`
+	TargetType string `json:"target-type"`
+	URL        *URL   `json:"url"`
 }
 
 type ReleaseGroup struct {
-	ID    string `json:"id"`
-	Title string `json:"title"`
-	Type  string `json:"primary-type"`
+	ID               string     `json:"id"`
+	Title            string     `json:"title"`
+	Type             string     `json:"primary-type"`
+	SecondaryTypes   []string   `json:"secondary-types"`
+	FirstReleaseDate string     `json:"first-release-date"`
+	Artists          []Artist   `json:"artist-credit"`
+	Releases         []Release  `json:"releases"`
+	Relations        []Relation `json:"relations"`
+	Tags             []Tag      `json:"tags"`
+	Genres           []Genre    `json:"genres"`
 }
 
+// Artist is an artist credit. Name is the name as credited on the release,
+// Details the credited artist itself.
 type Artist struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID         string        `json:"id"`
+	Name       string        `json:"name"`
+	JoinPhrase string        `json:"joinphrase"`
+	Details    ArtistDetails `json:"artist"`
+}
+
+type Disc struct {
+	ID      string `json:"id"`
+	Sectors int    `json:"sectors"`
+}
+
+type Medium struct {
+	Position   int     `json:"position"`
+	Format     string  `json:"format"`
+	Title      string  `json:"title"`
+	DiscCount  int     `json:"disc-count"`
+	TrackCount int     `json:"track-count"`
+	Discs      []Disc  `json:"discs"`
+	Tracks     []Track `json:"tracks"`
 }
 
 type Release struct {
-	ID           string       `json:"id"`
-	Title        string       `json:"title"`
-	Artists      []Artist     `json:"artist-credit"`
-	ReleaseGroup ReleaseGroup `json:"release-group"`
-	Date         string       `json:"date"`
-	Barcode      string       `json:"barcode"`
+	ID                 string             `json:"id"`
+	Title              string             `json:"title"`
+	Artists            []Artist           `json:"artist-credit"`
+	ReleaseGroup       ReleaseGroup       `json:"release-group"`
+	Date               string             `json:"date"`
+	Country            string             `json:"country"`
+	Status             string             `json:"status"`
+	Packaging          string             `json:"packaging"`
+	Barcode            string             `json:"barcode"`
+	TextRepresentation TextRepresentation `json:"text-representation"`
+	LabelInfo          []LabelInfo        `json:"label-info"`
+	Media              []Medium           `json:"media"`
+	Relations          []Relation         `json:"relations"`
+	Tags               []Tag              `json:"tags"`
+	Genres             []Genre            `json:"genres"`
+	CoverArtArchive    CoverArtArchive    `json:"cover-art-archive"`
 }
 
+// discPattern matches disc markers such as "(disc 2)", "[Disc 2]" or "(CD2)"
+// that MusicBrainz uses for discs released separately from a set.
+var discPattern = regexp.MustCompile(`(?i)\s*[(\[](?:disc|cd)\s*(\d+)[)\]]`)
+
 type SearchResponse struct {
 	Releases []Release `json:"releases"`
 	Count    int       `json:"count"`
@@ -48,49 +121,131 @@ func NewClient(baseURL string) *Client {
 	}
 }
 
-func (c *Client) SearchByBarcode(barcode string) (*Release, error) {
-	// MusicBrainz API endpoint for release search by barcode
-	endpoint := fmt.Sprintf("%s/release", c.BaseURL)
-
+// SearchByBarcode finds the release with the given barcode. Search results
+// include the artist credit, release group and each medium's format and track
+// count, but the search endpoint ignores inc: use GetRelease for anything
+// else, such as disc IDs.
+func (c *Client) SearchByBarcode(ctx context.Context, barcode string) (*Release, error) {
 	// Build query parameters
 	params := url.Values{}
 	params.Add("query", fmt.Sprintf("barcode:%s", barcode))
-	params.Add("fmt", "json")
-	params.Add("inc", "artists+release-groups")
+
+	var searchResp SearchResponse
+	if err := c.get(ctx, "release", params, &searchResp); err != nil {
+		return nil, err
+	}
+
+	// Check if we found any releases
+	if len(searchResp.Releases) == 0 {
+		return nil, fmt.Errorf("%w: no releases for barcode %s", ErrNotFound, barcode)
+	}
+
+	// Return the first release (most relevant)
+	return &searchResp.Releases[0], nil
+}
+
+// LookupDiscID finds the release a CD with the given MusicBrainz Disc ID
+// belongs to. If toc is not empty, MusicBrainz falls back to a fuzzy match on
+// the track layout when the Disc ID itself is unknown.
+func (c *Client) LookupDiscID(ctx context.Context, discID, toc string) (*Release, error) {
+	params := url.Values{}
+	params.Add("inc", strings.Join([]string{IncArtists, IncReleaseGroups, IncMedia, IncDiscIDs}, "+"))
+	if toc != "" {
+		params.Add("toc", toc)
+	}
+
+	var discResp SearchResponse
+	if err := c.get(ctx, "discid/"+url.PathEscape(discID), params, &discResp); err != nil {
+		return nil, err
+	}
+
+	if len(discResp.Releases) == 0 {
+		return nil, fmt.Errorf("%w: no releases for disc ID %s", ErrNotFound, discID)
+	}
+
+	return &discResp.Releases[0], nil
+}
+
+// get performs a JSON request against a MusicBrainz API path and decodes the
+// response into target.
+func (c *Client) get(ctx context.Context, path string, params url.Values, target interface{}) error {
+	params.Set("fmt", "json")
 
 	// Create request
-	req, err := http.NewRequest("GET", endpoint+"?"+params.Encode(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/%s?%s", c.BaseURL, path, params.Encode()), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set User-Agent header (required by MusicBrainz)
 	req.Header.Set("User-Agent", "barcode-music-player/1.0 (https://github.com/user/barcode-music-player)")
 
-	// Make request
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+		return fmt.Errorf("API request failed with status: %d", resp.StatusCode)
 	}
 
 	// Parse response
-	var searchResp SearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	// Check if we found any releases
-	if len(searchResp.Releases) == 0 {
-		return nil, fmt.Errorf("no releases found for barcode: %s", barcode)
+	return nil
+}
+
+// do sends a request within the rate limit, backing off and retrying while
+// MusicBrainz is unavailable.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.waitForRateLimit(req.Context()); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil || resp.StatusCode != http.StatusServiceUnavailable || attempt == maxUnavailableRetries {
+			return resp, err
+		}
+		resp.Body.Close()
+
+		if err := sleep(req.Context(), time.Duration(attempt+1)*minRequestInterval); err != nil {
+			return nil, err
+		}
 	}
+}
 
-	// Return the first release (most relevant)
-	return &searchResp.Releases[0], nil
+func (c *Client) waitForRateLimit(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if wait := minRequestInterval - time.Since(c.lastRequest); wait > 0 {
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+	c.lastRequest = time.Now()
+	return nil
+}
+
+// sleep waits for d, or until ctx is cancelled.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (r *Release) GetMainArtist() string {
@@ -103,20 +258,38 @@ func (r *Release) GetMainArtist() string {
 func (r *Release) GetSearchQuery() string {
 	// Create a more flexible search query
 	artist := r.GetMainArtist()
-	title := r.Title
-
-	// Clean up common issues in titles and artist names
-	title = strings.ReplaceAll(title, " (disc 1)", "")
-	title = strings.ReplaceAll(title, " (disc 2)", "")
-	title = strings.ReplaceAll(title, " [disc 1]", "")
-	title = strings.ReplaceAll(title, " [disc 2]", "")
 
-	// Remove common suffixes that might cause issues
-	title = strings.ReplaceAll(title, " (bonus track version)", "")
-	title = strings.ReplaceAll(title, " (deluxe edition)", "")
-	title = strings.ReplaceAll(title, " (remastered)", "")
-	title = strings.ReplaceAll(title, " (expanded edition)", "")
+	// Remove disc and edition markers and typographic characters that might cause issues
+	title := normalize.CleanTitle(r.Title)
 
 	// Return a simple search query without field specifiers
-	return fmt.Sprintf("%s %s", title, artist)
+	return fmt.Sprintf("%s %s", title, normalize.Fold(artist))
+}
+
+// DiscNumber returns the disc of a multi-disc set this release identifies, or
+// 0 if it identifies the whole release.
+func (r *Release) DiscNumber() int {
+	match := discPattern.FindStringSubmatch(r.Title)
+	if match == nil {
+		return 0
+	}
+
+	disc, _ := strconv.Atoi(match[1])
+	return disc
+}
+
+// MediumForDiscID returns the position of the medium with the given disc ID,
+// or 0 if none of the release's media has it.
+func (r *Release) MediumForDiscID(discID string) int {
+	for i, medium := range r.Media {
+		for _, disc := range medium.Discs {
+			if disc.ID == discID {
+				if medium.Position > 0 {
+					return medium.Position
+				}
+				return i + 1
+			}
+		}
+	}
+	return 0
 }
@@ -0,0 +1,82 @@
+package musicbrainz
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// StreamingLinks are the streaming and purchase pages MusicBrainz links to a
+// release or its release group.
+type StreamingLinks struct {
+	Spotify    []string
+	Deezer     []string
+	AppleMusic []string
+	Tidal      []string
+	Bandcamp   []string
+}
+
+func (l *StreamingLinks) add(resource string) {
+	parsed, err := url.Parse(resource)
+	if err != nil {
+		return
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsed.Host), "www.")
+	var links *[]string
+
+	switch {
+	case host == "open.spotify.com":
+		links = &l.Spotify
+	case host == "deezer.com":
+		links = &l.Deezer
+	case host == "music.apple.com" || host == "itunes.apple.com":
+		links = &l.AppleMusic
+	case host == "tidal.com" || host == "listen.tidal.com":
+		links = &l.Tidal
+	case host == "bandcamp.com" || strings.HasSuffix(host, ".bandcamp.com"):
+		links = &l.Bandcamp
+	default:
+		return
+	}
+
+	for _, existing := range *links {
+		if existing == resource {
+			return
+		}
+	}
+	*links = append(*links, resource)
+}
+
+func (l *StreamingLinks) addRelations(relations []Relation) {
+	for _, relation := range relations {
+		if relation.TargetType == "url" && relation.URL != nil {
+			l.add(relation.URL.Resource)
+		}
+	}
+}
+
+// GetStreamingLinks fetches the URL relationships of a release, and of its
+// release group unless the release already links to Spotify. Links on the
+// release itself come first since they point at the exact edition. The
+// release is looked up with inc in addition to url-rels and returned too, so
+// callers needing more details don't have to look it up again.
+func (c *Client) GetStreamingLinks(ctx context.Context, release *Release, inc ...string) (*StreamingLinks, *Release, error) {
+	links := &StreamingLinks{}
+
+	full, err := c.GetRelease(ctx, release.ID, append([]string{IncURLRels}, inc...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	links.addRelations(full.Relations)
+
+	if len(links.Spotify) == 0 && release.ReleaseGroup.ID != "" {
+		group, err := c.GetReleaseGroup(ctx, release.ReleaseGroup.ID, IncURLRels)
+		if err != nil {
+			return nil, full, err
+		}
+		links.addRelations(group.Relations)
+	}
+
+	return links, full, nil
+}
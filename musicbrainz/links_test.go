@@ -0,0 +1,67 @@
+package musicbrainz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetStreamingLinks(t *testing.T) {
+	tests := []struct {
+		name        string
+		releaseURL  string
+		wantPaths   []string
+		wantSpotify []string
+	}{
+		{
+			name:        "release links to Spotify",
+			releaseURL:  "https://open.spotify.com/album/release",
+			wantPaths:   []string{"/release/r1"},
+			wantSpotify: []string{"https://open.spotify.com/album/release"},
+		},
+		{
+			name:        "release group links to Spotify",
+			releaseURL:  "https://www.deezer.com/album/1",
+			wantPaths:   []string{"/release/r1", "/release-group/g1"},
+			wantSpotify: []string{"https://open.spotify.com/album/group"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				resource := "https://open.spotify.com/album/group"
+				if strings.HasPrefix(r.URL.Path, "/release/") {
+					if inc := r.URL.Query().Get("inc"); inc != "url-rels+labels" {
+						t.Errorf("release looked up with inc %q", inc)
+					}
+					resource = tt.releaseURL
+				}
+				fmt.Fprintf(w, `{"id": "r1", "relations": [{"target-type": "url", "url": {"resource": %q}}]}`, resource)
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL)
+			release := &Release{ID: "r1", ReleaseGroup: ReleaseGroup{ID: "g1"}}
+			links, full, err := client.GetStreamingLinks(context.Background(), release, IncLabels)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Join(paths, " ") != strings.Join(tt.wantPaths, " ") {
+				t.Errorf("requested %v, want %v", paths, tt.wantPaths)
+			}
+			if strings.Join(links.Spotify, " ") != strings.Join(tt.wantSpotify, " ") {
+				t.Errorf("Spotify links %v, want %v", links.Spotify, tt.wantSpotify)
+			}
+			if full == nil || len(full.Relations) != 1 {
+				t.Errorf("got release %+v, want the looked up release", full)
+			}
+		})
+	}
+}
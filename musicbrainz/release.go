@@ -0,0 +1,196 @@
+package musicbrainz
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values for the inc parameter of release and release group lookups.
+const (
+	IncArtists       = "artists"
+	IncArtistCredits = "artist-credits"
+	IncReleaseGroups = "release-groups"
+	IncReleases      = "releases"
+	IncRecordings    = "recordings"
+	IncMedia         = "media"
+	IncDiscIDs       = "discids"
+	IncLabels        = "labels"
+	IncISRCs         = "isrcs"
+	IncURLRels       = "url-rels"
+	IncTags          = "tags"
+	IncGenres        = "genres"
+	IncAliases       = "aliases"
+)
+
+type Recording struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Length  int      `json:"length"`
+	ISRCs   []string `json:"isrcs"`
+	Artists []Artist `json:"artist-credit"`
+}
+
+type Track struct {
+	ID        string    `json:"id"`
+	Number    string    `json:"number"`
+	Position  int       `json:"position"`
+	Title     string    `json:"title"`
+	Length    int       `json:"length"`
+	Recording Recording `json:"recording"`
+}
+
+// Duration returns the track length, which MusicBrainz reports in milliseconds.
+func (t *Track) Duration() time.Duration {
+	return time.Duration(t.Length) * time.Millisecond
+}
+
+type Label struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type LabelInfo struct {
+	CatalogNumber string `json:"catalog-number"`
+	Label         *Label `json:"label"`
+}
+
+type Tag struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type Genre struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type TextRepresentation struct {
+	Language string `json:"language"`
+	Script   string `json:"script"`
+}
+
+// GetRelease fetches a release by MBID with the given inc values, e.g.
+// IncRecordings to get the full tracklist.
+func (c *Client) GetRelease(ctx context.Context, mbid string, inc ...string) (*Release, error) {
+	params := url.Values{}
+	if len(inc) > 0 {
+		params.Add("inc", strings.Join(inc, "+"))
+	}
+
+	var release Release
+	if err := c.get(ctx, "release/"+url.PathEscape(mbid), params, &release); err != nil {
+		return nil, fmt.Errorf("failed to get release %s: %w", mbid, err)
+	}
+
+	return &release, nil
+}
+
+// GetReleaseGroup fetches a release group by MBID with the given inc values.
+func (c *Client) GetReleaseGroup(ctx context.Context, mbid string, inc ...string) (*ReleaseGroup, error) {
+	params := url.Values{}
+	if len(inc) > 0 {
+		params.Add("inc", strings.Join(inc, "+"))
+	}
+
+	var group ReleaseGroup
+	if err := c.get(ctx, "release-group/"+url.PathEscape(mbid), params, &group); err != nil {
+		return nil, fmt.Errorf("failed to get release group %s: %w", mbid, err)
+	}
+
+	return &group, nil
+}
+
+// Tracks returns the tracks of the given disc, or of all media in disc order
+// if disc is 0. They are only available on releases fetched with
+// IncRecordings.
+func (r *Release) Tracks(disc int) []Track {
+	var tracks []Track
+	for i, medium := range r.Media {
+		position := medium.Position
+		if position == 0 {
+			position = i + 1
+		}
+		if disc > 0 && position != disc {
+			continue
+		}
+		tracks = append(tracks, medium.Tracks...)
+	}
+	return tracks
+}
+
+// TrackCount returns the number of tracks across all media.
+func (r *Release) TrackCount() int {
+	count := 0
+	for _, medium := range r.Media {
+		count += medium.TrackCount
+	}
+	return count
+}
+
+// Year returns the release year, or 0 if the release date is unknown.
+func (r *Release) Year() int {
+	var year int
+	if len(r.Date) >= 4 {
+		fmt.Sscanf(r.Date[:4], "%d", &year)
+	}
+	return year
+}
+
+// OriginalYear returns the year the release group was first released, falling
+// back to the release's own year.
+func (r *Release) OriginalYear() int {
+	var year int
+	if len(r.ReleaseGroup.FirstReleaseDate) >= 4 {
+		fmt.Sscanf(r.ReleaseGroup.FirstReleaseDate[:4], "%d", &year)
+	}
+	if year == 0 {
+		return r.Year()
+	}
+	return year
+}
+
+// Format summarizes the release's media, e.g. "CD" or "2×CD".
+func (r *Release) Format() string {
+	if len(r.Media) == 0 {
+		return ""
+	}
+
+	format := r.Media[0].Format
+	for _, medium := range r.Media[1:] {
+		if medium.Format != format {
+			format = "Mixed"
+			break
+		}
+	}
+	if format == "" {
+		format = "Unknown"
+	}
+
+	if len(r.Media) > 1 {
+		return fmt.Sprintf("%d×%s", len(r.Media), format)
+	}
+	return format
+}
+
+// Details summarizes format, track count, year and country for display.
+func (r *Release) Details() string {
+	var details []string
+	if format := r.Format(); format != "" {
+		details = append(details, format)
+	}
+	if count := r.TrackCount(); count > 0 {
+		details = append(details, fmt.Sprintf("%d tracks", count))
+	}
+	if year := r.Year(); year > 0 {
+		details = append(details, strconv.Itoa(year))
+	}
+	if r.Country != "" {
+		details = append(details, r.Country)
+	}
+	return strings.Join(details, ", ")
+}
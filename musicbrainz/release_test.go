@@ -0,0 +1,40 @@
+package musicbrainz
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReleaseTracks(t *testing.T) {
+	r := &Release{Media: []Medium{
+		{Position: 1, Tracks: []Track{{Title: "1-1"}, {Title: "1-2"}}},
+		{Position: 2, Tracks: []Track{{Title: "2-1", Length: 61500}}},
+	}}
+
+	tests := []struct {
+		disc int
+		want []string
+	}{
+		{0, []string{"1-1", "1-2", "2-1"}},
+		{1, []string{"1-1", "1-2"}},
+		{2, []string{"2-1"}},
+		{3, nil},
+	}
+
+	for _, tt := range tests {
+		tracks := r.Tracks(tt.disc)
+		if len(tracks) != len(tt.want) {
+			t.Errorf("Tracks(%d) = %v, want %v", tt.disc, tracks, tt.want)
+			continue
+		}
+		for i, track := range tracks {
+			if track.Title != tt.want[i] {
+				t.Errorf("Tracks(%d)[%d] = %s, want %s", tt.disc, i, track.Title, tt.want[i])
+			}
+		}
+	}
+
+	if got := r.Tracks(2)[0].Duration(); got != 61500*time.Millisecond {
+		t.Errorf("Duration() = %s, want 1m1.5s", got)
+	}
+}
@@ -0,0 +1,112 @@
+package normalize
+
+import (
+	"strings"
+	"unicode"
+)
+
+// foldGroups maps the base letters to the accented letters folded into them.
+var foldGroups = map[string]string{
+	"a": "àáâãäåāăąǎȁȃạảấầẩẫậắằẳẵặ", "A": "ÀÁÂÃÄÅĀĂĄǍȀȂẠẢẤẦẨẪẬẮẰẲẴẶ",
+	"c": "çćĉċč", "C": "ÇĆĈĊČ",
+	"d": "ďđð", "D": "ĎĐÐ",
+	"e": "èéêëēĕėęěȅȇẹẻẽếềểễệ", "E": "ÈÉÊËĒĔĖĘĚȄȆẸẺẼẾỀỂỄỆ",
+	"g": "ĝğġģ", "G": "ĜĞĠĢ",
+	"h": "ĥħ", "H": "ĤĦ",
+	"i": "ìíîïĩīĭįıǐȉȋịỉ", "I": "ÌÍÎÏĨĪĬĮİǏȈȊỊỈ",
+	"j": "ĵ", "J": "Ĵ",
+	"k": "ķ", "K": "Ķ",
+	"l": "ĺļľŀł", "L": "ĹĻĽĿŁ",
+	"n": "ñńņňŉ", "N": "ÑŃŅŇ",
+	"o": "òóôõöøōŏőǒȍȏọỏốồổỗộớờởỡợơ", "O": "ÒÓÔÕÖØŌŎŐǑȌȎỌỎỐỒỔỖỘỚỜỞỠỢƠ",
+	"r": "ŕŗř", "R": "ŔŖŘ",
+	"s": "śŝşšș", "S": "ŚŜŞŠȘ",
+	"t": "ţťŧț", "T": "ŢŤŦȚ",
+	"u": "ùúûüũūŭůűųǔưụủứừửữự", "U": "ÙÚÛÜŨŪŬŮŰŲǓƯỤỦỨỪỬỮỰ",
+	"w": "ŵ", "W": "Ŵ",
+	"y": "ýÿŷỳỵỷỹ", "Y": "ÝŸŶỲỴỶỸ",
+	"z": "źżž", "Z": "ŹŻŽ",
+	"ae": "æ", "AE": "Æ",
+	"oe": "œ", "OE": "Œ",
+	"ss": "ß",
+	"th": "þ", "TH": "Þ",
+}
+
+// punctuation maps typographic punctuation to its ASCII equivalent.
+var punctuation = map[rune]string{
+	'‘': "'", '’': "'", '‚': "'", '‛': "'", '′': "'", '`': "'", '´': "'",
+	'“': `"`, '”': `"`, '„': `"`, '‟': `"`, '″': `"`, '«': `"`, '»': `"`,
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-", '−': "-",
+	'…':      "...",
+	'\u00a0': " ", '\u3000': " ",
+	'・': " ", '·': " ",
+}
+
+var folds = buildFolds()
+
+func buildFolds() map[rune]string {
+	folds := make(map[rune]string)
+	for base, accented := range foldGroups {
+		for _, r := range accented {
+			folds[r] = base
+		}
+	}
+	for r, replacement := range punctuation {
+		folds[r] = replacement
+	}
+	return folds
+}
+
+// Fold removes diacritics from Latin letters and replaces full-width
+// characters and typographic punctuation with their ASCII equivalents. Other
+// scripts are left alone, and case is kept.
+func Fold(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for _, r := range s {
+		switch {
+		case r >= '！' && r <= '～':
+			// Full-width ASCII variants
+			b.WriteRune(r - 0xFEE0)
+		case folds[r] != "":
+			b.WriteString(folds[r])
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return strings.TrimSpace(spacePattern.ReplaceAllString(b.String(), " "))
+}
+
+// Text returns a comparison form of s: folded, lowercased, with "&" spelled
+// out and punctuation removed.
+func Text(s string) string {
+	s = strings.ToLower(Fold(s))
+	s = strings.ReplaceAll(s, "&", " and ")
+
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\'':
+			// Keep contractions together: "don't" matches "dont"
+			return -1
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			return r
+		default:
+			return ' '
+		}
+	}, s)
+
+	return strings.TrimSpace(spacePattern.ReplaceAllString(s, " "))
+}
+
+// IsLatin reports whether all letters of s are in the Latin script, so it can
+// be searched for as-is.
+func IsLatin(s string) bool {
+	for _, r := range Fold(s) {
+		if unicode.IsLetter(r) && !unicode.Is(unicode.Latin, r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package normalize
+
+import "testing"
+
+func TestFold(t *testing.T) {
+	tests := map[string]string{
+		"Beyoncé":               "Beyonce",
+		"Motörhead":             "Motorhead",
+		"Sigur Rós":             "Sigur Ros",
+		"Straße":                "Strasse",
+		"Ænima":                 "AEnima",
+		"Don’t Stop":            "Don't Stop",
+		"“Heroes”":              `"Heroes"`,
+		"Songs — Live":          "Songs - Live",
+		"Wait…":                 "Wait...",
+		"ＡＢＣ　１２３":               "ABC 123",
+		"  Too   many\tspaces ": "Too many spaces",
+		"坂本龍一":                  "坂本龍一",
+		"Кино":                  "Кино",
+	}
+
+	for in, want := range tests {
+		if got := Fold(in); got != want {
+			t.Errorf("Fold(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := map[string]string{
+		"Simon & Garfunkel": "simon and garfunkel",
+		"Don’t Stop Me Now": "dont stop me now",
+		"AC/DC":             "ac dc",
+		"Beyoncé":           "beyonce",
+		"What's Going On?":  "whats going on",
+		"Sgt. Pepper's":     "sgt peppers",
+		"  ":                "",
+		"Café Tacvba – Re":  "cafe tacvba re",
+		"ＤＩＳＣＯ　ＩＮＦＥＲＮＯ": "disco inferno",
+	}
+
+	for in, want := range tests {
+		if got := Text(in); got != want {
+			t.Errorf("Text(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestIsLatin(t *testing.T) {
+	tests := map[string]bool{
+		"Sigur Rós":       true,
+		"Ｔｏｋｙｏ":           true,
+		"123 !?":          true,
+		"坂本龍一":            false,
+		"Кино":            false,
+		"Yellow Magic 坂本": false,
+	}
+
+	for in, want := range tests {
+		if got := IsLatin(in); got != want {
+			t.Errorf("IsLatin(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// bracketPattern matches a bracketed part of a title in any bracket style
+	bracketPattern = regexp.MustCompile(`\s*[(\[{]([^)\]}]*)[)\]}]`)
+
+	// dashPattern matches a trailing " - Remastered 2009" style suffix
+	dashPattern = regexp.MustCompile(`\s+[-–—]\s+([^-–—]+)$`)
+
+	spacePattern = regexp.MustCompile(`\s+`)
+)
+
+// editionPattern marks a bracketed or dashed title suffix as describing the
+// edition rather than the album, in the languages we commonly see.
+var editionPattern = regexp.MustCompile(`(?i)(edition|edición|édition|edizione|ausgabe|` +
+	`deluxe|expanded|anniversary|bonus|special|collector|` +
+	`remaster|reissue|\bmono\b|\bstereo\b|` +
+	`\bclean\b|\bedited\b|\bexplicit\b|` +
+	`\bdisc\s*\d+|\bcd\s*\d+)`)
+
+var (
+	remasterKeywords = []string{"remaster", "remasteriz", "remasteris"}
+	deluxeKeywords   = []string{"deluxe", "expanded", "anniversary", "bonus", "special", "collector", "especial", "spéciale", "speciale"}
+	cleanKeywords    = []string{"clean", "edited", "censored"}
+	explicitKeywords = []string{"explicit"}
+)
+
+// Edition describes what a title says about the edition of an album.
+type Edition struct {
+	Remaster bool
+	Deluxe   bool
+	Clean    bool
+	Explicit bool
+}
+
+// CleanTitle folds a title and removes edition and disc markers such as
+// "(Deluxe Edition)", "[Remastered]" or " - Remastered 2009", keeping its case.
+func CleanTitle(title string) string {
+	cleaned := Fold(title)
+	cleaned = bracketPattern.ReplaceAllStringFunc(cleaned, func(segment string) string {
+		if isEditionSuffix(segment) {
+			return ""
+		}
+		return segment
+	})
+
+	if match := dashPattern.FindStringSubmatch(cleaned); match != nil && isEditionSuffix(match[1]) {
+		cleaned = strings.TrimSuffix(cleaned, match[0])
+	}
+
+	return strings.TrimSpace(spacePattern.ReplaceAllString(cleaned, " "))
+}
+
+// Key returns a comparison key for a title or artist name: the cleaned title
+// in its Text form.
+func Key(title string) string {
+	return Text(CleanTitle(title))
+}
+
+// DetectEdition reports which edition markers a title carries.
+func DetectEdition(title string) Edition {
+	title = Fold(title)
+
+	var suffixes []string
+	for _, match := range bracketPattern.FindAllStringSubmatch(title, -1) {
+		suffixes = append(suffixes, match[1])
+	}
+	if match := dashPattern.FindStringSubmatch(title); match != nil {
+		suffixes = append(suffixes, match[1])
+	}
+
+	var edition Edition
+	for _, suffix := range suffixes {
+		suffix = strings.ToLower(suffix)
+		edition.Remaster = edition.Remaster || containsAny(suffix, remasterKeywords)
+		edition.Deluxe = edition.Deluxe || containsAny(suffix, deluxeKeywords)
+		edition.Clean = edition.Clean || containsAny(suffix, cleanKeywords)
+		edition.Explicit = edition.Explicit || containsAny(suffix, explicitKeywords)
+	}
+	return edition
+}
+
+func isEditionSuffix(segment string) bool {
+	return editionPattern.MatchString(segment)
+}
+
+func containsAny(s string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(s, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package normalize
+
+import "testing"
+
+func TestCleanTitle(t *testing.T) {
+	tests := map[string]string{
+		"Abbey Road":                               "Abbey Road",
+		"Abbey Road (Remastered)":                  "Abbey Road",
+		"Abbey Road (Super Deluxe Edition)":        "Abbey Road",
+		"Abbey Road - Remastered 2009":             "Abbey Road",
+		"Abbey Road [2019 Mix] (Deluxe)":           "Abbey Road [2019 Mix]",
+		"The Wall (Disc 2)":                        "The Wall",
+		"Rumours (Expanded & Remastered)":          "Rumours",
+		"Nevermind {Édition Deluxe}":               "Nevermind",
+		"Blonde (Explicit)":                        "Blonde",
+		"Sticky Fingers (Live)":                    "Sticky Fingers (Live)",
+		"Love - Hate":                              "Love - Hate",
+		"Let It Be… Naked (Remastered)":            "Let It Be... Naked",
+		"Pet Sounds (Mono & Stereo)":               "Pet Sounds",
+		"Mezzanine - 2019 Remaster":                "Mezzanine",
+		"(What's the Story) Morning Glory?":        "(What's the Story) Morning Glory?",
+		"Dark Side of the Moon [50th Anniversary]": "Dark Side of the Moon",
+	}
+
+	for in, want := range tests {
+		if got := CleanTitle(in); got != want {
+			t.Errorf("CleanTitle(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	pairs := [][2]string{
+		{"Abbey Road", "Abbey Road (Remastered 2009)"},
+		{"Sigur Rós", "Sigur Ros"},
+		{"Simon & Garfunkel", "Simon and Garfunkel"},
+		{"Don't Look Back", "Don’t Look Back - Deluxe Edition"},
+	}
+
+	for _, pair := range pairs {
+		if a, b := Key(pair[0]), Key(pair[1]); a != b {
+			t.Errorf("Key(%q) = %q, Key(%q) = %q, want them equal", pair[0], a, pair[1], b)
+		}
+	}
+}
+
+func TestDetectEdition(t *testing.T) {
+	tests := map[string]Edition{
+		"Abbey Road":                        {},
+		"Abbey Road (Remastered)":           {Remaster: true},
+		"Mezzanine - 2019 Remaster":         {Remaster: true},
+		"Rumours (Super Deluxe)":            {Deluxe: true},
+		"Abbey Road (Remastered) [Deluxe]":  {Remaster: true, Deluxe: true},
+		"Blonde (Explicit)":                 {Explicit: true},
+		"Blonde (Clean)":                    {Clean: true},
+		"Edición Especial Remasterizada":    {},
+		"Grandes Éxitos (Edición Especial)": {Deluxe: true},
+		"Rumours (Édition Spéciale)":        {Deluxe: true},
+		"Hits (Edited Version)":             {Clean: true},
+	}
+
+	for in, want := range tests {
+		if got := DetectEdition(in); got != want {
+			t.Errorf("DetectEdition(%q) = %+v, want %+v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package player
+
+import (
+	"context"
+	"fmt"
+
+	"barcode-music-player/collection"
+	"barcode-music-player/config"
+	"barcode-music-player/input"
+	"barcode-music-player/musicbrainz"
+)
+
+func openCollection(cfg *config.Config) (*collection.Store, error) {
+	if !cfg.CollectionEnabled {
+		return nil, nil
+	}
+	return collection.Open(cfg.CollectionFile)
+}
+
+// Collection returns the catalog of scanned releases, or nil if it is
+// disabled.
+func (p *Player) Collection() *collection.Store {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+
+	return p.collection
+}
+
+// Catalog processes a scan as in catalog mode, whatever the play mode: the
+// release is looked up in MusicBrainz and added to the collection, and
+// nothing is played.
+func (p *Player) Catalog(ctx context.Context, event input.Event) (ScanResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.scan(ctx, event, p.cfg.DryRun, true)
+}
+
+// catalog adds a scanned release to the collection without searching Spotify.
+func (p *Player) catalog(ctx context.Context, event input.Event, result *ScanResult) error {
+	if p.collection == nil {
+		return fmt.Errorf("the collection is disabled, set COLLECTION_ENABLED=true to catalog releases")
+	}
+
+	p.logf("📚 Cataloging %s (via %s)", event.Barcode, event.Source)
+
+	res, toc, err := parseScan(event.Barcode, event.Source)
+	if err != nil {
+		return err
+	}
+	if mapping, ok := p.mappings.Get(res.key()); ok {
+		res.Mapping = &mapping
+	}
+
+	if err := p.lookupRelease(ctx, res, toc); err != nil {
+		return err
+	}
+	result.ReleaseID = res.Release.ID
+	result.Album, result.Artist = res.title()
+
+	if result.DryRun {
+		result.Resolution = res
+		p.plan(Plan{Action: ActionCatalog})
+		return nil
+	}
+
+	p.collect(ctx, res)
+	return nil
+}
+
+// detailIncs are the inc values of the release lookups the collection and
+// ranking need: the labels, media, tracklist and release group search results
+// lack, and the URL relationships that streaming links are looked up with
+// anyway.
+var detailIncs = []string{
+	musicbrainz.IncArtistCredits, musicbrainz.IncLabels, musicbrainz.IncMedia,
+	musicbrainz.IncRecordings, musicbrainz.IncReleaseGroups,
+}
+
+// collect records a scanned release in the collection. New releases need the
+// labels, links and cover art search results lack: they come from the lookup
+// made for the streaming links, or else from a lookup of their own.
+// Failing to save is only a warning: it mustn't stop the album from playing.
+func (p *Player) collect(ctx context.Context, res *Resolution) {
+	if p.collection == nil || res.Release == nil {
+		return
+	}
+
+	release := res.Release
+	_, known := p.collection.Get(res.key())
+	switch {
+	case known:
+	case res.details != nil:
+		release = res.details
+	default:
+		full, err := p.musicbrainz.GetRelease(ctx, release.ID, append(detailIncs, musicbrainz.IncURLRels)...)
+		if err != nil {
+			p.logf("⚠️  Warning: Could not get the release details: %v", err)
+		} else {
+			release = full
+		}
+	}
+
+	item := collection.FromRelease(res.key(), release)
+	switch {
+	case res.Album != nil:
+		item.AlbumURI = res.Album.URI
+	case res.Mapping != nil:
+		item.AlbumURI = res.Mapping.AlbumURI
+	}
+
+	item, err := p.collection.Record(item, p.current.Time)
+	if err != nil {
+		p.logf("⚠️  Warning: Could not record the release in the collection: %v", err)
+		return
+	}
+
+	if item.Scans == 1 {
+		p.logf("📚 Added \"%s\" by %s to the collection", item.Title, item.Artist)
+	} else {
+		p.logf("📚 \"%s\" is in the collection (scanned %d times)", item.Title, item.Scans)
+	}
+}
@@ -0,0 +1,107 @@
+package player
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"barcode-music-player/config"
+	"barcode-music-player/discid"
+	"barcode-music-player/input"
+)
+
+// RunCommand runs one of the commands in config.Commands.
+func (p *Player) RunCommand(ctx context.Context, command string) error {
+	if !config.IsCommand(command) {
+		return fmt.Errorf("unknown command %q", command)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.runCommand(ctx, command)
+}
+
+func (p *Player) runCommand(ctx context.Context, command string) error {
+	switch command {
+	case config.CommandToggleMode:
+		if p.Mode() == config.ModePlay {
+			p.setMode(config.ModeQueue)
+			p.logf("📥 Queue mode: scanned albums will be added to the queue")
+		} else {
+			p.setMode(config.ModePlay)
+			p.logf("▶️  Play mode: scanned albums will play immediately")
+		}
+	case config.CommandToggleCatalog:
+		if p.Mode() != config.ModeCatalog {
+			if p.collection == nil {
+				return fmt.Errorf("the collection is disabled, set COLLECTION_ENABLED=true to catalog releases")
+			}
+			p.setMode(config.ModeCatalog)
+			p.logf("📚 Catalog mode: scanned releases will be added to the collection without playing")
+		} else if p.cfg.PlayMode == config.ModeQueue {
+			p.setMode(config.ModeQueue)
+			p.logf("📥 Queue mode: scanned albums will be added to the queue")
+		} else {
+			p.setMode(config.ModePlay)
+			p.logf("▶️  Play mode: scanned albums will play immediately")
+		}
+	case config.CommandShowQueue:
+		p.showQueue(ctx)
+	case config.CommandClearQueue:
+		p.queue.Clear()
+		p.logf("🧹 Cleared the list of queued albums (tracks already sent to Spotify stay queued)")
+	case config.CommandReadCD:
+		p.logf("💿 Reading TOC from %s...", p.cfg.CDDevice)
+		toc, err := discid.ReadDrive(p.cfg.CDDevice)
+		if err != nil {
+			return err
+		}
+		_, err = p.scan(ctx, input.Event{
+			Barcode:   "toc:" + toc.String(),
+			Source:    "cd",
+			Timestamp: time.Now(),
+		}, p.cfg.DryRun, p.Mode() == config.ModeCatalog)
+		return err
+	case config.CommandPause:
+		p.logf("⏸️  Pausing...")
+		return p.spotify.Pause(ctx)
+	case config.CommandResume:
+		p.logf("▶️  Resuming...")
+		return p.spotify.Resume(ctx)
+	case config.CommandNext:
+		p.logf("⏭️  Skipping to next track...")
+		return p.spotify.Next(ctx)
+	case config.CommandPrevious:
+		p.logf("⏮️  Going back to previous track...")
+		return p.spotify.Previous(ctx)
+	case config.CommandReplay:
+		return p.replay(ctx)
+	}
+	return nil
+}
+
+func (p *Player) showQueue(ctx context.Context) {
+	var currentTrack string
+	state, err := p.spotify.GetPlaybackState(ctx)
+	if err != nil {
+		p.logf("⚠️  Warning: Could not get playback state: %v", err)
+	} else if state != nil && state.Item != nil {
+		currentTrack = state.Item.URI
+	}
+
+	entries, playing := p.queue.Sync(currentTrack)
+	if len(entries) == 0 {
+		p.logf("📭 No scanned albums pending in the queue")
+		return
+	}
+
+	p.logf("📋 Scanned albums in the queue:")
+	for i, entry := range entries {
+		marker := fmt.Sprintf("%d.", i+1)
+		if playing && i == 0 {
+			marker = "▶️ "
+		}
+		p.logf("   %s \"%s\" by %s (%d tracks)", marker, entry.AlbumName, entry.Artist, len(entry.TrackURIs))
+	}
+}
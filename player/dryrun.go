@@ -0,0 +1,64 @@
+package player
+
+import (
+	"context"
+
+	"barcode-music-player/spotify"
+)
+
+// Actions a dry run can plan.
+const (
+	ActionPlay        = "play"
+	ActionQueue       = "queue"
+	ActionRestart     = "restart"
+	ActionIgnore      = "ignore"
+	ActionPause       = "pause"
+	ActionResume      = "resume"
+	ActionNext        = "next"
+	ActionPlayTracks  = "play-tracks"
+	ActionQueueTracks = "queue-tracks"
+	ActionCommand     = "command"
+	ActionCatalog     = "catalog"
+)
+
+// Plan is what a dry-run scan would have done.
+type Plan struct {
+	Action    string   `json:"action"`
+	Command   string   `json:"command,omitempty"`
+	AlbumURI  string   `json:"album_uri,omitempty"`
+	TrackURIs []string `json:"track_uris,omitempty"`
+	// Options and Device are set for actions that start playback
+	Options *spotify.PlayOptions `json:"options,omitempty"`
+	Device  *spotify.Device      `json:"device,omitempty"`
+}
+
+// dryRun reports whether the scan being processed must not change playback.
+func (p *Player) dryRun() bool {
+	return p.current != nil && p.current.DryRun
+}
+
+// plan records an action that doesn't start playback.
+func (p *Player) plan(plan Plan) {
+	if plan.Action == ActionCommand {
+		p.logf("🧪 Dry run: would run the %s command", plan.Command)
+	} else {
+		p.logf("🧪 Dry run: would %s", plan.Action)
+	}
+	p.current.Plan = &plan
+}
+
+// planPlayback records that playback would start with opts, and the device it
+// would start on. Like playing, it fails if there is no device to play on.
+func (p *Player) planPlayback(ctx context.Context, plan Plan, opts spotify.PlayOptions) error {
+	plan.Options = &opts
+	p.current.Plan = &plan
+
+	device, err := p.spotify.ChooseDevice(ctx, opts.Device)
+	if err != nil {
+		return err
+	}
+
+	plan.Device = device
+	p.logf("🧪 Dry run: would %s on %s (%s)", plan.Action, device.Name, device.Type)
+	return nil
+}
@@ -0,0 +1,75 @@
+package player
+
+import (
+	"fmt"
+)
+
+// Event types sent to subscribers.
+const (
+	// EventStep is a pipeline step, such as a lookup or a warning
+	EventStep = "step"
+	// EventScan is the result of a finished scan
+	EventScan = "scan"
+)
+
+// Event is a step of the scan pipeline or the result of a scan.
+type Event struct {
+	Type string `json:"type"`
+	// Scan is the scan being processed, if any
+	Scan    string      `json:"scan,omitempty"`
+	Message string      `json:"message,omitempty"`
+	Result  *ScanResult `json:"result,omitempty"`
+}
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before events are dropped for it.
+const subscriberBuffer = 64
+
+// Subscribe returns a channel receiving pipeline events, and a function to
+// stop the subscription.
+func (p *Player) Subscribe() (<-chan Event, func()) {
+	events := make(chan Event, subscriberBuffer)
+
+	p.stateMu.Lock()
+	p.subscribers[events] = struct{}{}
+	p.stateMu.Unlock()
+
+	return events, func() {
+		p.stateMu.Lock()
+		defer p.stateMu.Unlock()
+
+		if _, ok := p.subscribers[events]; ok {
+			delete(p.subscribers, events)
+			close(events)
+		}
+	}
+}
+
+func (p *Player) publish(event Event) {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+
+	for events := range p.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// logf prints a pipeline step, records it for the scan being processed and
+// sends it to subscribers.
+func (p *Player) logf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	fmt.Fprintln(p.out, message)
+
+	if p.current != nil {
+		p.current.Steps = append(p.current.Steps, message)
+	}
+
+	event := Event{Type: EventStep, Message: message}
+	if p.current != nil {
+		event.Scan = p.current.Scan
+	}
+	p.publish(event)
+}
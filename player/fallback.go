@@ -0,0 +1,112 @@
+package player
+
+import (
+	"context"
+	"fmt"
+
+	"barcode-music-player/config"
+	"barcode-music-player/musicbrainz"
+	"barcode-music-player/normalize"
+	"barcode-music-player/spotify"
+)
+
+// playTrackFallback is used when the album itself can't be found on Spotify.
+// It looks up each recording by ISRC (or by title and artist) and plays the
+// tracks that were found as an ad-hoc list, in disc order. Like playAlbum, it
+// returns the action taken.
+func (p *Player) playTrackFallback(ctx context.Context, release *musicbrainz.Release, disc int, opts spotify.PlayOptions) (string, error) {
+	p.logf("🧩 Album not found, looking for its tracks individually...")
+
+	full, err := p.musicbrainz.GetRelease(ctx, release.ID, musicbrainz.IncRecordings, musicbrainz.IncISRCs, musicbrainz.IncArtistCredits)
+	if err != nil {
+		return "", fmt.Errorf("failed to get tracklist: %w", err)
+	}
+
+	tracks := full.Tracks(disc)
+	if len(tracks) == 0 {
+		return "", fmt.Errorf("MusicBrainz has no tracklist for \"%s\"", release.Title)
+	}
+
+	var (
+		uris    []string
+		missing []string
+	)
+	for _, track := range tracks {
+		uri := p.findTrack(ctx, track, release.GetMainArtist())
+		if uri == "" {
+			missing = append(missing, fmt.Sprintf("%s. %s", track.Number, track.Title))
+			continue
+		}
+		uris = append(uris, uri)
+	}
+
+	p.logf("🧩 Found %d of %d tracks on Spotify", len(uris), len(tracks))
+	if len(missing) > 0 {
+		p.logf("⚠️  Missing tracks:")
+		for _, track := range missing {
+			p.logf("   - %s", track)
+		}
+	}
+
+	if len(uris) == 0 {
+		return "", fmt.Errorf("none of the tracks of \"%s\" are on Spotify", release.Title)
+	}
+
+	state, err := p.spotify.GetPlaybackState(ctx)
+	if err == nil && p.Mode() == config.ModeQueue && state != nil && state.IsPlaying {
+		if p.dryRun() {
+			p.plan(Plan{Action: ActionQueueTracks, TrackURIs: uris})
+			return ActionQueueTracks, nil
+		}
+		for _, uri := range uris {
+			if err := p.spotify.AddToQueue(ctx, uri); err != nil {
+				return ActionQueueTracks, fmt.Errorf("failed to queue tracks: %w", err)
+			}
+		}
+		p.logf("📥 Queued %d tracks of \"%s\"", len(uris), release.Title)
+		return ActionQueueTracks, nil
+	}
+
+	if p.dryRun() {
+		return ActionPlayTracks, p.planPlayback(ctx, Plan{Action: ActionPlayTracks, TrackURIs: uris}, opts)
+	}
+
+	p.logf("▶️  Playing tracks...")
+	if err := p.spotify.PlayTracks(ctx, uris, opts); err != nil {
+		return ActionPlayTracks, fmt.Errorf("failed to play tracks: %w", err)
+	}
+
+	p.logf("🎉 Successfully playing %d tracks of \"%s\" by %s", len(uris), release.Title, release.GetMainArtist())
+	return ActionPlayTracks, nil
+}
+
+// findTrack returns the Spotify URI of a MusicBrainz track, trying its ISRCs
+// first and then a title and artist search.
+func (p *Player) findTrack(ctx context.Context, track musicbrainz.Track, releaseArtist string) string {
+	for _, isrc := range track.Recording.ISRCs {
+		results, err := p.spotify.SearchTracks(ctx, "isrc:"+isrc)
+		if err == nil && len(results) > 0 {
+			return results[0].URI
+		}
+	}
+
+	artist := releaseArtist
+	if len(track.Recording.Artists) > 0 {
+		artist = track.Recording.Artists[0].Name
+	}
+
+	results, err := p.spotify.SearchTracks(ctx, spotify.TrackQuery(normalize.CleanTitle(track.Title), artist))
+	if err != nil {
+		return ""
+	}
+
+	// Spotify titles often carry a " - Remastered" suffix or different
+	// punctuation
+	title := normalize.Key(track.Title)
+	for _, result := range results {
+		if normalize.Key(result.Name) == title {
+			return result.URI
+		}
+	}
+	return ""
+}
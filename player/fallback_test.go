@@ -0,0 +1,108 @@
+package player
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"barcode-music-player/musicbrainz"
+	"barcode-music-player/spotify"
+)
+
+// roundTripper answers requests with a handler instead of sending them to
+// Spotify.
+type roundTripper struct {
+	handler http.HandlerFunc
+}
+
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorder := httptest.NewRecorder()
+	rt.handler(recorder, req)
+	return recorder.Result(), nil
+}
+
+// newTestPlayer returns a player whose Spotify requests are answered by
+// handler.
+func newTestPlayer(handler http.HandlerFunc) *Player {
+	client := spotify.NewClient("id", "secret", "http://127.0.0.1/callback")
+	client.AccessToken = "token"
+	client.HTTPClient = &http.Client{Transport: roundTripper{handler}}
+	client.Output = io.Discard
+	return &Player{spotify: client, out: io.Discard}
+}
+
+func TestFindTrack(t *testing.T) {
+	tests := []struct {
+		name      string
+		title     string
+		results   []string
+		wantQuery string
+		want      string
+	}{
+		{
+			name:      "exact title",
+			title:     "Come Together",
+			results:   []string{"Come Together"},
+			wantQuery: `track:"Come Together" artist:"The Beatles"`,
+			want:      "spotify:track:0",
+		},
+		{
+			name:      "remastered suffix",
+			title:     "Come Together",
+			results:   []string{"Come Together (Live)", "Come Together - Remastered 2009"},
+			wantQuery: `track:"Come Together" artist:"The Beatles"`,
+			want:      "spotify:track:1",
+		},
+		{
+			name:      "curly apostrophe",
+			title:     "Don’t Let Me Down",
+			results:   []string{"Don't Let Me Down"},
+			wantQuery: `track:"Don't Let Me Down" artist:"The Beatles"`,
+			want:      "spotify:track:0",
+		},
+		{
+			name:      "quotes in the title",
+			title:     `The "Fish" Song`,
+			results:   []string{`The "Fish" Song`},
+			wantQuery: `track:"The Fish Song" artist:"The Beatles"`,
+			want:      "spotify:track:0",
+		},
+		{
+			name:      "different track",
+			title:     "Something",
+			results:   []string{"Something Else"},
+			wantQuery: `track:"Something" artist:"The Beatles"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlayer(func(w http.ResponseWriter, r *http.Request) {
+				if query := r.URL.Query().Get("q"); query != tt.wantQuery {
+					t.Errorf("searched for %q, want %q", query, tt.wantQuery)
+				}
+
+				var response struct {
+					Tracks struct {
+						Items []spotify.Track `json:"items"`
+					} `json:"tracks"`
+				}
+				for i, name := range tt.results {
+					response.Tracks.Items = append(response.Tracks.Items, spotify.Track{
+						Name: name,
+						URI:  "spotify:track:" + string(rune('0'+i)),
+					})
+				}
+				json.NewEncoder(w).Encode(response)
+			})
+
+			track := musicbrainz.Track{Title: tt.title}
+			if got := p.findTrack(context.Background(), track, "The Beatles"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package player
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"barcode-music-player/config"
+	"barcode-music-player/history"
+	"barcode-music-player/musicbrainz"
+	"barcode-music-player/spotify"
+)
+
+func openHistory(cfg *config.Config) *history.Log {
+	if !cfg.HistoryEnabled {
+		return nil
+	}
+	return history.Open(cfg.HistoryFile)
+}
+
+// History returns the scan history, or nil if it is disabled.
+func (p *Player) History() *history.Log {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+
+	return p.history
+}
+
+// outcome classifies how a scan ended.
+func outcome(result *ScanResult, err error) string {
+	switch {
+	case err == nil && result.DryRun:
+		return history.OutcomeDryRun
+	case err == nil && result.Command != "":
+		return history.OutcomeCommand
+	case err == nil && result.Catalog:
+		return history.OutcomeCataloged
+	case err == nil:
+		switch result.Action {
+		case ActionQueue, ActionQueueTracks:
+			return history.OutcomeQueued
+		case ActionPause:
+			return history.OutcomePaused
+		case ActionResume:
+			return history.OutcomeResumed
+		case ActionNext:
+			return history.OutcomeSkipped
+		case ActionIgnore:
+			return history.OutcomeIgnored
+		}
+		return history.OutcomePlayed
+	case errors.Is(err, musicbrainz.ErrNotFound):
+		return history.OutcomeNotFound
+	case errors.Is(err, ErrNotOnSpotify):
+		return history.OutcomeNotOnSpotify
+	case errors.Is(err, spotify.ErrRegionRestricted):
+		return history.OutcomeRegionRestricted
+	case errors.Is(err, spotify.ErrNoDevice), errors.Is(err, spotify.ErrDeviceNotFound):
+		return history.OutcomeNoDevice
+	case errors.Is(err, spotify.ErrPremiumRequired):
+		return history.OutcomePremiumRequired
+	}
+	return history.OutcomeFailed
+}
+
+func (p *Player) appendHistory(result ScanResult) {
+	if p.history == nil {
+		return
+	}
+
+	err := p.history.Append(history.Entry{
+		Time:      result.Time,
+		Barcode:   result.Scan,
+		Source:    result.Source,
+		Profile:   p.cfg.Profile,
+		Command:   result.Command,
+		ReleaseID: result.ReleaseID,
+		Album:     result.Album,
+		Artist:    result.Artist,
+		AlbumURI:  result.AlbumURI,
+		Outcome:   result.Outcome,
+		Error:     result.Error,
+		DryRun:    result.DryRun,
+	})
+	if err != nil {
+		p.logf("⚠️  Warning: Could not record scan in the history: %v", err)
+	}
+}
+
+// replay plays the last REPLAY_COUNT albums from the history again, in the
+// order they were played: the first is played and the others are queued
+// after it.
+func (p *Player) replay(ctx context.Context) error {
+	if p.history == nil {
+		return fmt.Errorf("the history is disabled, set HISTORY_ENABLED=true to replay albums")
+	}
+
+	entries, err := p.history.Read(history.Filter{Outcome: history.OutcomePlayed})
+	if err != nil {
+		return err
+	}
+
+	// The most recent distinct albums; tracks played without an album can't
+	// be replayed
+	var albums []history.Entry
+	seen := make(map[string]bool)
+	for i := len(entries) - 1; i >= 0 && len(albums) < p.cfg.ReplayCount; i-- {
+		entry := entries[i]
+		if entry.AlbumURI == "" || seen[entry.AlbumURI] {
+			continue
+		}
+		seen[entry.AlbumURI] = true
+		albums = append(albums, entry)
+	}
+
+	if len(albums) == 0 {
+		return fmt.Errorf("no albums in the history to replay")
+	}
+
+	p.logf("🔂 Replaying the last %d album(s)...", len(albums))
+	for i := len(albums) - 1; i >= 0; i-- {
+		entry := albums[i]
+
+		albumID, ok := spotify.ParseAlbumLink(entry.AlbumURI)
+		if !ok {
+			return fmt.Errorf("invalid album in history: %s", entry.AlbumURI)
+		}
+		album, err := p.spotify.GetAlbum(ctx, albumID)
+		if err != nil {
+			return fmt.Errorf("failed to get \"%s\": %w", entry.Album, err)
+		}
+
+		if i == len(albums)-1 {
+			mapping, _ := p.mappings.Get(entry.Barcode)
+			_, err = p.playAlbum(ctx, album, 0, mapping)
+		} else {
+			err = p.queueAlbum(ctx, album, 0)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package player
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"barcode-music-player/config"
+	"barcode-music-player/mappings"
+	"barcode-music-player/queue"
+	"barcode-music-player/spotify"
+)
+
+// playAlbum plays a Spotify album with the configured playback options and
+// those of the scanned barcode's mapping, starting at the given disc if it is
+// part of a multi-disc set. It returns the action taken, one of the Action*
+// values: the album may have been queued, or a rescan may have paused it.
+func (p *Player) playAlbum(ctx context.Context, album *spotify.Album, disc int, mapping mappings.Mapping) (string, error) {
+	// Step 3: Handle rescans of the album that is already playing
+	state, err := p.spotify.GetPlaybackState(ctx)
+	if err != nil {
+		p.logf("⚠️  Warning: Could not get playback state: %v", err)
+	} else if state.IsPlayingContext(album.URI) {
+		return p.applyRescanPolicy(ctx, state, album, disc, mapping)
+	}
+
+	// Step 4: Queue the album if something is already playing in queue mode
+	if p.Mode() == config.ModeQueue && state != nil && state.IsPlaying {
+		return ActionQueue, p.queueAlbum(ctx, album, disc)
+	}
+
+	// Step 5: Play the album
+	opts := p.albumOptions(ctx, album, disc, mapping)
+	if p.dryRun() {
+		return ActionPlay, p.planPlayback(ctx, Plan{Action: ActionPlay, AlbumURI: album.URI}, opts)
+	}
+
+	p.logf("▶️  Playing album...")
+	if err := p.spotify.PlayAlbum(ctx, album.URI, opts); err != nil {
+		return ActionPlay, fmt.Errorf("failed to play album: %w", err)
+	}
+
+	p.logf("🎉 Successfully playing: \"%s\" by %s", album.Name, album.GetMainArtist())
+	return ActionPlay, nil
+}
+
+// playOptions returns the configured playback options with the mapping's
+// overrides applied.
+func (p *Player) playOptions(mapping mappings.Mapping) spotify.PlayOptions {
+	return mapping.Apply(spotify.PlayOptions{
+		Shuffle: p.cfg.PlayShuffle,
+		Repeat:  p.cfg.PlayRepeat,
+		Volume:  p.cfg.PlayVolume,
+		Device:  p.cfg.PlayDevice,
+	})
+}
+
+// albumOptions returns the playback options for an album, starting at the
+// given disc unless the mapping picks a start track.
+func (p *Player) albumOptions(ctx context.Context, album *spotify.Album, disc int, mapping mappings.Mapping) spotify.PlayOptions {
+	opts := p.playOptions(mapping)
+	if !mapping.HasStart() {
+		opts.Position = p.discPosition(ctx, album, disc)
+	}
+	return opts
+}
+
+// applyRescanPolicy handles a scan of the album that is already playing, and
+// returns the action taken.
+func (p *Player) applyRescanPolicy(ctx context.Context, state *spotify.PlaybackState, album *spotify.Album, disc int, mapping mappings.Mapping) (string, error) {
+	if p.dryRun() {
+		return p.planRescan(ctx, state, album, disc, mapping)
+	}
+
+	switch p.cfg.RescanPolicy {
+	case config.RescanIgnore:
+		p.logf("⏭️  \"%s\" is already playing, ignoring scan", album.Name)
+		return ActionIgnore, nil
+	case config.RescanTogglePause:
+		if state.IsPlaying {
+			p.logf("⏸️  Album already playing, pausing...")
+			return ActionPause, p.spotify.Pause(ctx)
+		}
+		p.logf("▶️  Album already loaded, resuming...")
+		return ActionResume, p.spotify.Resume(ctx)
+	case config.RescanNext:
+		p.logf("⏭️  Album already playing, skipping to next track...")
+		return ActionNext, p.spotify.Next(ctx)
+	}
+
+	p.logf("🔁 Album already playing, restarting...")
+	if err := p.spotify.PlayAlbum(ctx, album.URI, p.albumOptions(ctx, album, disc, mapping)); err != nil {
+		return ActionRestart, fmt.Errorf("failed to restart album: %w", err)
+	}
+	return ActionRestart, nil
+}
+
+// planRescan records what applyRescanPolicy would do.
+func (p *Player) planRescan(ctx context.Context, state *spotify.PlaybackState, album *spotify.Album, disc int, mapping mappings.Mapping) (string, error) {
+	p.logf("🔁 \"%s\" is already playing, rescan policy is %s", album.Name, p.cfg.RescanPolicy)
+
+	plan := Plan{AlbumURI: album.URI}
+	switch p.cfg.RescanPolicy {
+	case config.RescanIgnore:
+		plan.Action = ActionIgnore
+	case config.RescanTogglePause:
+		plan.Action = ActionResume
+		if state.IsPlaying {
+			plan.Action = ActionPause
+		}
+	case config.RescanNext:
+		plan.Action = ActionNext
+	default:
+		plan.Action = ActionRestart
+		return plan.Action, p.planPlayback(ctx, plan, p.albumOptions(ctx, album, disc, mapping))
+	}
+
+	p.plan(plan)
+	return plan.Action, nil
+}
+
+func (p *Player) queueAlbum(ctx context.Context, album *spotify.Album, disc int) error {
+	p.logf("📥 Fetching tracklist...")
+	tracks, err := p.spotify.GetAlbumTracks(ctx, album.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get album tracks: %w", err)
+	}
+
+	// Only queue the scanned disc of a multi-disc set
+	if disc > 0 {
+		var discTracks []spotify.Track
+		for _, track := range tracks {
+			if track.DiscNumber == disc {
+				discTracks = append(discTracks, track)
+			}
+		}
+		if len(discTracks) > 0 {
+			tracks = discTracks
+		}
+	}
+
+	if p.dryRun() {
+		plan := Plan{Action: ActionQueue, AlbumURI: album.URI}
+		for _, track := range tracks {
+			plan.TrackURIs = append(plan.TrackURIs, track.URI)
+		}
+		p.plan(plan)
+		return nil
+	}
+
+	entry := queue.Entry{
+		AlbumName: album.Name,
+		Artist:    album.GetMainArtist(),
+		AlbumURI:  album.URI,
+		QueuedAt:  time.Now(),
+	}
+
+	for _, track := range tracks {
+		if err := p.spotify.AddToQueue(ctx, track.URI); err != nil {
+			if len(entry.TrackURIs) == 0 {
+				return fmt.Errorf("failed to queue album: %w", err)
+			}
+			p.logf("⚠️  Warning: Only queued %d of %d tracks: %v", len(entry.TrackURIs), len(tracks), err)
+			break
+		}
+		entry.TrackURIs = append(entry.TrackURIs, track.URI)
+	}
+
+	p.queue.Add(entry)
+	p.logf("📥 Queued %d tracks of \"%s\" by %s", len(entry.TrackURIs), album.Name, album.GetMainArtist())
+
+	p.showQueue(ctx)
+	return nil
+}
+
+// discPosition returns the position of the first track of disc on album,
+// falling back to the first track if the disc can't be found.
+func (p *Player) discPosition(ctx context.Context, album *spotify.Album, disc int) int {
+	if disc <= 1 {
+		return 0
+	}
+
+	tracks, err := p.spotify.GetAlbumTracks(ctx, album.ID)
+	if err != nil {
+		p.logf("⚠️  Warning: Could not get tracks to find disc %d: %v", disc, err)
+		return 0
+	}
+
+	position, ok := spotify.DiscStart(tracks, disc)
+	if !ok {
+		p.logf("⚠️  Warning: \"%s\" has no disc %d on Spotify, starting from the beginning", album.Name, disc)
+		return 0
+	}
+
+	p.logf("💿 Starting at disc %d (track %d)", disc, position+1)
+	return position
+}
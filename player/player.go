@@ -0,0 +1,352 @@
+package player
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"barcode-music-player/collection"
+	"barcode-music-player/config"
+	"barcode-music-player/history"
+	"barcode-music-player/input"
+	"barcode-music-player/mappings"
+	"barcode-music-player/match"
+	"barcode-music-player/musicbrainz"
+	"barcode-music-player/queue"
+	"barcode-music-player/spotify"
+)
+
+// maxRecentScans is how many scans are kept for the status API.
+const maxRecentScans = 50
+
+// Player runs the scan pipeline: it resolves barcodes and disc IDs to Spotify
+// albums and plays or queues them. Scans and commands are processed one at a
+// time, whichever input or API they come from.
+type Player struct {
+	cfg         *config.Config
+	spotify     *spotify.Client
+	musicbrainz *musicbrainz.Client
+	mappings    *mappings.Store
+	queue       *queue.Queue
+	preference  match.Preference
+	// out receives the pipeline steps as they happen
+	out io.Writer
+	// history is nil when the history is disabled
+	history *history.Log
+	// collection is nil when the collection is disabled
+	collection *collection.Store
+	// playlists caches the IDs of the synced playlists by name, and
+	// playlistAlbums the albums in them by ID, guarded by mu
+	playlists      map[string]string
+	playlistAlbums map[string]*playlistAlbums
+
+	// mu serializes scans and commands
+	mu sync.Mutex
+	// current is the scan being processed, guarded by mu
+	current *ScanResult
+
+	stateMu     sync.Mutex
+	mode        string
+	recent      []ScanResult
+	subscribers map[chan Event]struct{}
+}
+
+// ScanResult records what a scan did.
+type ScanResult struct {
+	Scan    string    `json:"scan"`
+	Source  string    `json:"source"`
+	Time    time.Time `json:"time"`
+	Command string    `json:"command,omitempty"`
+	// ReleaseID is the MusicBrainz release the scan was identified as
+	ReleaseID string `json:"release_id,omitempty"`
+	Album     string `json:"album,omitempty"`
+	Artist    string `json:"artist,omitempty"`
+	AlbumURI  string `json:"album_uri,omitempty"`
+	// Action is what was done with the album, one of the Action* values
+	Action string `json:"action,omitempty"`
+	// Outcome is one of the history.Outcome* values
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+	// Steps are the messages logged while processing the scan
+	Steps []string `json:"steps,omitempty"`
+
+	// Catalog scans are added to the collection without playing
+	Catalog bool `json:"catalog,omitempty"`
+
+	// DryRun scans resolve the album but leave playback alone. Their result
+	// includes the resolution and what would have been done.
+	DryRun     bool        `json:"dry_run,omitempty"`
+	Resolution *Resolution `json:"resolution,omitempty"`
+	Plan       *Plan       `json:"plan,omitempty"`
+}
+
+// Status is a snapshot of the player.
+type Status struct {
+	Mode     string                 `json:"mode"`
+	Playback *spotify.PlaybackState `json:"playback"`
+	// Queue lists the scanned albums still pending in the Spotify queue
+	Queue        []queue.Entry `json:"queue"`
+	QueuePlaying bool          `json:"queue_playing"`
+}
+
+// New creates a player that prints its steps to out. The Spotify client must
+// already be authenticated.
+func New(cfg *config.Config, spotifyClient *spotify.Client, musicbrainzClient *musicbrainz.Client, out io.Writer) (*Player, error) {
+	preference, err := match.ParsePreference(cfg.EditionPreference)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := mappings.Open(cfg.MappingsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := openCollection(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Player{
+		cfg:            cfg,
+		spotify:        spotifyClient,
+		musicbrainz:    musicbrainzClient,
+		mappings:       store,
+		queue:          queue.New(),
+		preference:     preference,
+		out:            out,
+		history:        openHistory(cfg),
+		collection:     catalog,
+		playlists:      make(map[string]string),
+		playlistAlbums: make(map[string]*playlistAlbums),
+		mode:           cfg.PlayMode,
+		subscribers:    make(map[chan Event]struct{}),
+	}, nil
+}
+
+// Mappings returns the store of per-barcode albums and playback options.
+func (p *Player) Mappings() *mappings.Store {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+
+	return p.mappings
+}
+
+// Reload applies a new configuration once the scan in progress, if any, has
+// finished. The play mode is reset to the configured one.
+func (p *Player) Reload(cfg *config.Config) error {
+	preference, err := match.ParsePreference(cfg.EditionPreference)
+	if err != nil {
+		return err
+	}
+
+	store, err := mappings.Open(cfg.MappingsFile)
+	if err != nil {
+		return err
+	}
+
+	catalog, err := openCollection(cfg)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+
+	p.cfg = cfg
+	p.preference = preference
+	p.mappings = store
+	p.history = openHistory(cfg)
+	p.collection = catalog
+	p.playlists = make(map[string]string)
+	p.playlistAlbums = make(map[string]*playlistAlbums)
+	p.mode = cfg.PlayMode
+	return nil
+}
+
+// Wait waits up to timeout for the scan or command in progress to finish, and
+// reports whether it did.
+func (p *Player) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		p.mu.Lock()
+		p.mu.Unlock()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Mode returns the current play mode.
+func (p *Player) Mode() string {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+
+	return p.mode
+}
+
+func (p *Player) setMode(mode string) {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+
+	p.mode = mode
+}
+
+// Scan processes a scan from any input: a command barcode, an album barcode
+// or one of the disc prefixes handled by Resolve. With DRY_RUN set, scans are
+// processed as by DryRun, and in catalog mode as by Catalog.
+func (p *Player) Scan(ctx context.Context, event input.Event) (ScanResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.scan(ctx, event, p.cfg.DryRun, p.Mode() == config.ModeCatalog)
+}
+
+// DryRun processes a scan like Scan, including every lookup and search, but
+// doesn't play, queue or run commands. The result records the resolution and
+// the plan of what would have been done.
+func (p *Player) DryRun(ctx context.Context, event input.Event) (ScanResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.scan(ctx, event, true, p.Mode() == config.ModeCatalog)
+}
+
+func (p *Player) scan(ctx context.Context, event input.Event, dryRun, catalog bool) (ScanResult, error) {
+	result := ScanResult{
+		Scan:    event.Barcode,
+		Source:  event.Source,
+		Time:    event.Timestamp,
+		Catalog: catalog,
+		DryRun:  dryRun,
+	}
+	if result.Time.IsZero() {
+		result.Time = time.Now()
+	}
+
+	previous := p.current
+	p.current = &result
+	defer func() { p.current = previous }()
+
+	var err error
+	if command, ok := p.cfg.CommandBarcodes[event.Barcode]; ok {
+		result.Command = command
+		if dryRun {
+			p.plan(Plan{Action: ActionCommand, Command: command})
+		} else {
+			err = p.runCommand(ctx, command)
+		}
+	} else if catalog {
+		err = p.catalog(ctx, event, &result)
+	} else {
+		err = p.process(ctx, event, &result)
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.Outcome = outcome(&result, err)
+	if result.Outcome == history.OutcomePlayed && result.AlbumURI != "" {
+		p.sync(ctx, result.AlbumURI)
+	}
+	p.appendHistory(result)
+
+	p.record(result)
+	p.publish(Event{Type: EventScan, Scan: result.Scan, Result: &result})
+
+	return result, err
+}
+
+func (p *Player) process(ctx context.Context, event input.Event, result *ScanResult) error {
+	p.logf("🔍 Processing %s (via %s)", event.Barcode, event.Source)
+
+	res, err := p.resolve(ctx, event.Barcode, event.Source)
+	if res != nil {
+		if result.DryRun {
+			result.Resolution = res
+		}
+		if res.Release != nil {
+			result.ReleaseID = res.Release.ID
+		}
+		result.Album, result.Artist = res.title()
+		if res.Album != nil {
+			result.AlbumURI = res.Album.URI
+		}
+	}
+
+	if err != nil {
+		// Without a Spotify album, fall back to the release's individual
+		// tracks. Other errors, such as failed searches or region
+		// restrictions, wouldn't be helped by searching for each track.
+		if !errors.Is(err, ErrNotOnSpotify) || res == nil || res.Release == nil {
+			return err
+		}
+		p.logf("⚠️  %v", err)
+		result.Action, err = p.playTrackFallback(ctx, res.Release, res.Disc, p.playOptions(res.mapping()))
+	} else {
+		result.Action, err = p.playAlbum(ctx, res.Album, res.Disc, res.mapping())
+	}
+	if err != nil {
+		return err
+	}
+
+	// Catalog the release once playback has started
+	if !result.DryRun {
+		p.collect(ctx, res)
+	}
+	return nil
+}
+
+func (p *Player) record(result ScanResult) {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+
+	p.recent = append(p.recent, result)
+	if len(p.recent) > maxRecentScans {
+		p.recent = p.recent[len(p.recent)-maxRecentScans:]
+	}
+}
+
+// RecentScans returns the latest scans, most recent first.
+func (p *Player) RecentScans() []ScanResult {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+
+	scans := make([]ScanResult, len(p.recent))
+	for i, result := range p.recent {
+		scans[len(p.recent)-1-i] = result
+	}
+	return scans
+}
+
+// Status returns the play mode, Spotify's playback state and the scanned
+// albums pending in the queue.
+func (p *Player) Status(ctx context.Context) (*Status, error) {
+	state, err := p.spotify.GetPlaybackState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var currentTrack string
+	if state != nil && state.Item != nil {
+		currentTrack = state.Item.URI
+	}
+	entries, playing := p.queue.Sync(currentTrack)
+
+	return &Status{
+		Mode:         p.Mode(),
+		Playback:     state,
+		Queue:        entries,
+		QueuePlaying: playing,
+	}, nil
+}
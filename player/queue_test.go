@@ -0,0 +1,67 @@
+package player
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"barcode-music-player/queue"
+	"barcode-music-player/spotify"
+)
+
+func TestStatusSyncsQueue(t *testing.T) {
+	var current string
+	p := newTestPlayer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/me/player" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if current == "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewEncoder(w).Encode(spotify.PlaybackState{IsPlaying: true, Item: &spotify.Track{URI: current}})
+	})
+	p.queue = queue.New()
+	for _, album := range []string{"a", "b"} {
+		p.queue.Add(queue.Entry{
+			AlbumName: album,
+			TrackURIs: []string{"spotify:track:" + album + "1", "spotify:track:" + album + "2"},
+			QueuedAt:  time.Now(),
+		})
+	}
+
+	steps := []struct {
+		current     string
+		want        []string
+		wantPlaying bool
+	}{
+		{"", []string{"a", "b"}, false},
+		{"spotify:track:other", []string{"a", "b"}, false},
+		{"spotify:track:a2", []string{"a", "b"}, true},
+		{"spotify:track:b1", []string{"b"}, true},
+		{"", []string{"b"}, false},
+		{"spotify:track:other", nil, false},
+	}
+
+	for _, step := range steps {
+		current = step.current
+		status, err := p.Status(context.Background())
+		if err != nil {
+			t.Fatalf("Status with %q playing: %v", step.current, err)
+		}
+
+		var got []string
+		for _, entry := range status.Queue {
+			got = append(got, entry.AlbumName)
+		}
+		if !slices.Equal(got, step.want) || status.QueuePlaying != step.wantPlaying {
+			t.Errorf("with %q playing: queue = %v, playing = %v, want %v, %v",
+				step.current, got, status.QueuePlaying, step.want, step.wantPlaying)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package player
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"barcode-music-player/discid"
+	"barcode-music-player/mappings"
+	"barcode-music-player/match"
+	"barcode-music-player/musicbrainz"
+	"barcode-music-player/spotify"
+)
+
+// ErrNotOnSpotify is returned when a release was found in MusicBrainz but
+// Spotify has no album for it.
+var ErrNotOnSpotify = errors.New("no albums found on Spotify")
+
+// Resolution is what a scan resolved to.
+type Resolution struct {
+	Scan string `json:"scan"`
+	// DiscID is set for scans that identify a CD by its table of contents
+	DiscID  string               `json:"disc_id,omitempty"`
+	Mapping *mappings.Mapping    `json:"mapping,omitempty"`
+	Release *musicbrainz.Release `json:"release,omitempty"`
+	// Disc is the disc of a multi-disc set the scan identifies, or 0
+	Disc int `json:"disc,omitempty"`
+	// Tracks is the tracklist of the release, or of the disc, if MusicBrainz
+	// was asked for it
+	Tracks     []musicbrainz.Track `json:"tracks,omitempty"`
+	Album      *spotify.Album      `json:"album,omitempty"`
+	Candidates []match.Candidate   `json:"candidates,omitempty"`
+	Strategies []string            `json:"strategies,omitempty"`
+	// Searches lists the Spotify queries that were tried
+	Searches []spotify.SearchAttempt `json:"searches,omitempty"`
+	// Error is why the release couldn't be resolved to a Spotify album
+	Error string `json:"error,omitempty"`
+
+	// details is the release looked up with detailIncs along with its
+	// streaming links, nil if it wasn't
+	details *musicbrainz.Release
+}
+
+func (r *Resolution) mapping() mappings.Mapping {
+	if r.Mapping == nil {
+		return mappings.Mapping{}
+	}
+	return *r.Mapping
+}
+
+// title returns the album name and artist, from Spotify if the album was
+// found and from MusicBrainz otherwise.
+func (r *Resolution) title() (album, artist string) {
+	switch {
+	case r.Album != nil:
+		return r.Album.Name, r.Album.GetMainArtist()
+	case r.Release != nil:
+		return r.Release.Title, r.Release.GetMainArtist()
+	}
+	return "", ""
+}
+
+// Resolve finds the Spotify album for a scan without playing it. Besides
+// barcodes, it accepts "discid:<id>", "toc:<first last leadout offsets...>"
+// and "tocfile:<path>" (a cdrdao TOC file, only from the "cli" and "stdin"
+// sources) to identify CDs without a barcode.
+//
+// If the release was found in MusicBrainz but not on Spotify, both the
+// resolution and an error are returned, and the resolution's Error is set.
+func (p *Player) Resolve(ctx context.Context, scan, source string) (*Resolution, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	res, err := p.resolve(ctx, scan, source)
+	if res != nil && err != nil {
+		res.Error = err.Error()
+	}
+	return res, err
+}
+
+func (p *Player) resolve(ctx context.Context, scan, source string) (*Resolution, error) {
+	res, toc, err := parseScan(scan, source)
+	if err != nil {
+		return nil, err
+	}
+
+	if mapping, ok := p.mappings.Get(res.key()); ok {
+		res.Mapping = &mapping
+		if mapping.AlbumURI != "" {
+			album, err := p.mappedAlbum(ctx, mapping)
+			if err != nil {
+				return nil, err
+			}
+			res.Album = album
+			return res, nil
+		}
+	}
+
+	// Step 1: Look up album in MusicBrainz
+	if err := p.lookupRelease(ctx, res, toc); err != nil {
+		return nil, err
+	}
+
+	// Step 2: Find the album on Spotify
+	if err := p.findAlbum(ctx, res); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// localSources are the scan sources trusted to read files on this machine.
+var localSources = map[string]bool{"cli": true, "stdin": true}
+
+// parseScan handles the disc prefixes of a scan, returning the disc's TOC
+// for "toc:" and "tocfile:" scans. TOC files are only read for scans typed
+// on this machine, as any other source could read arbitrary files.
+func parseScan(scan, source string) (res *Resolution, toc string, err error) {
+	res = &Resolution{Scan: scan}
+
+	switch {
+	case strings.HasPrefix(scan, "discid:"):
+		res.DiscID = strings.TrimPrefix(scan, "discid:")
+	case strings.HasPrefix(scan, "toc:"):
+		parsed, err := discid.ParseTOC(strings.TrimPrefix(scan, "toc:"))
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid TOC: %w", err)
+		}
+		res.DiscID, toc = parsed.DiscID(), parsed.String()
+	case strings.HasPrefix(scan, "tocfile:"):
+		if !localSources[source] {
+			return nil, "", fmt.Errorf("TOC files can only be read from the command line or standard input")
+		}
+
+		file, err := os.Open(strings.TrimPrefix(scan, "tocfile:"))
+		if err != nil {
+			var pathErr *fs.PathError
+			if errors.As(err, &pathErr) {
+				err = pathErr.Err
+			}
+			return nil, "", fmt.Errorf("failed to open TOC file: %w", err)
+		}
+		defer file.Close()
+
+		parsed, err := discid.ParseCdrdao(file)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid TOC file: %w", err)
+		}
+		res.DiscID, toc = parsed.DiscID(), parsed.String()
+	}
+
+	return res, toc, nil
+}
+
+// key returns what mappings and the collection are keyed by: the barcode, or
+// the disc ID for CDs.
+func (r *Resolution) key() string {
+	if r.DiscID != "" {
+		return r.DiscID
+	}
+	return r.Scan
+}
+
+// lookupRelease finds the scanned release in MusicBrainz.
+func (p *Player) lookupRelease(ctx context.Context, res *Resolution, toc string) error {
+	if res.DiscID != "" {
+		p.logf("💿 Looking up disc ID %s in MusicBrainz...", res.DiscID)
+		release, err := p.musicbrainz.LookupDiscID(ctx, res.DiscID, toc)
+		if err != nil {
+			return fmt.Errorf("failed to find album for disc ID %s: %w", res.DiscID, err)
+		}
+		res.Release, res.Disc = release, release.MediumForDiscID(res.DiscID)
+	} else {
+		p.logf("🔍 Looking up album in MusicBrainz...")
+		release, err := p.musicbrainz.SearchByBarcode(ctx, res.Scan)
+		if err != nil {
+			return fmt.Errorf("failed to find album for barcode %s: %w", res.Scan, err)
+		}
+		res.Release, res.Disc = release, release.DiscNumber()
+	}
+
+	p.printRelease(res.Release)
+	return nil
+}
+
+// searchFailed reports whether every search attempt failed, such as when the
+// network is down, rather than finding nothing.
+func searchFailed(attempts []spotify.SearchAttempt) bool {
+	if len(attempts) == 0 {
+		return false
+	}
+	for _, attempt := range attempts {
+		if attempt.Error == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// mappedAlbum gets the album a mapping pins its barcode to.
+func (p *Player) mappedAlbum(ctx context.Context, mapping mappings.Mapping) (*spotify.Album, error) {
+	albumID, ok := spotify.ParseAlbumLink(mapping.AlbumURI)
+	if !ok {
+		return nil, fmt.Errorf("invalid album in mapping for %s: %s", mapping.Barcode, mapping.AlbumURI)
+	}
+
+	p.logf("📌 Using mapped album...")
+	album, err := p.spotify.GetAlbum(ctx, albumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mapped album: %w", err)
+	}
+
+	p.logf("🎯 Mapped to: \"%s\" by %s", album.Name, album.GetMainArtist())
+	return album, nil
+}
+
+// findAlbum resolves the release to a Spotify album, preferring a Spotify
+// link recorded in MusicBrainz over a text search.
+func (p *Player) findAlbum(ctx context.Context, res *Resolution) error {
+	release := res.Release
+
+	p.logf("🔗 Checking MusicBrainz for streaming links...")
+	links, details, err := p.musicbrainz.GetStreamingLinks(ctx, release, detailIncs...)
+	if details != nil {
+		res.details = details
+		res.Tracks = details.Tracks(res.Disc)
+	}
+	if err != nil {
+		p.logf("⚠️  Warning: Could not get streaming links: %v", err)
+	} else {
+		for _, link := range links.Spotify {
+			albumID, ok := spotify.ParseAlbumLink(link)
+			if !ok {
+				continue
+			}
+
+			album, err := p.spotify.GetAlbum(ctx, albumID)
+			if err != nil {
+				p.logf("⚠️  Warning: Could not use linked album %s: %v", link, err)
+				continue
+			}
+
+			p.logf("🎯 Found Spotify link: \"%s\" by %s", album.Name, album.GetMainArtist())
+			res.Album = album
+			res.Strategies = []string{"musicbrainz-link"}
+			return nil
+		}
+	}
+
+	// Names in non-Latin scripts often need a transliteration to be found
+	var alternates *musicbrainz.AlternateNames
+	if release.NeedsAlternateNames() {
+		p.logf("🔤 Looking up Latin-script names in MusicBrainz...")
+		alternates, err = p.musicbrainz.GetAlternateNames(ctx, release)
+		if err != nil {
+			p.logf("⚠️  Warning: Could not get alternate names: %v", err)
+		}
+	}
+
+	p.logf("🎵 Searching for album on Spotify...")
+	titles, artists := release.SearchNames(alternates)
+	result, err := p.spotify.SearchAlbums(ctx, spotify.SearchRequest{
+		Titles:   titles,
+		Artists:  artists,
+		Year:     release.OriginalYear(),
+		Keywords: []string{release.GetSearchQuery()},
+		Accept: func(album spotify.Album) bool {
+			return match.Confident(release, alternates, album, p.preference)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to search Spotify: %w", err)
+	}
+	res.Searches = result.Attempts
+
+	albums := result.Albums()
+	if len(albums) == 0 {
+		if len(result.Restricted) > 0 {
+			restricted := result.Restricted[0]
+			return fmt.Errorf("%w (%s): \"%s\" by %s", spotify.ErrRegionRestricted, p.spotify.Market, restricted.Name, restricted.GetMainArtist())
+		}
+		// When every search failed, Spotify wasn't reachable rather than
+		// lacking the album
+		if searchFailed(result.Attempts) {
+			return fmt.Errorf("failed to search Spotify: %s", result.Attempts[0].Error)
+		}
+		return fmt.Errorf("%w: %s", ErrNotOnSpotify, release.GetSearchQuery())
+	}
+
+	// Explicit/clean preferences need the tracklists, which search results lack
+	if p.preference.NeedsTracks() {
+		ids := make([]string, len(albums))
+		for i, album := range albums {
+			ids[i] = album.ID
+		}
+		if full, err := p.spotify.GetAlbums(ctx, ids); err != nil {
+			p.logf("⚠️  Warning: Could not get album details: %v", err)
+		} else if playable := spotify.FilterPlayable(full); len(playable) > 0 {
+			albums = playable
+		}
+	}
+
+	// The details carry the tracklist to compare track lengths with
+	ranked := release
+	if res.details != nil {
+		ranked = res.details
+	}
+	res.Candidates = match.Rank(ranked, alternates, albums, p.preference)
+
+	// Editions scoring almost the same are told apart by their track
+	// lengths, which need the full albums
+	if tied := match.Close(res.Candidates); len(tied) > 1 && len(tied[0].Tracks.Items) == 0 && len(res.Tracks) > 0 {
+		ids := make([]string, len(tied))
+		for i, album := range tied {
+			ids[i] = album.ID
+		}
+		if full, err := p.spotify.GetAlbums(ctx, ids); err != nil {
+			p.logf("⚠️  Warning: Could not get album details: %v", err)
+		} else {
+			res.Candidates = match.Rank(ranked, alternates, replaceAlbums(albums, full), p.preference)
+		}
+	}
+	for i, candidate := range res.Candidates {
+		if i == 3 {
+			break
+		}
+		p.logf("   %2.0f  \"%s\" by %s (%s)", candidate.Score, candidate.Album.Name, candidate.Album.GetMainArtist(), strings.Join(candidate.Reasons, ", "))
+	}
+
+	album := res.Candidates[0].Album
+	res.Album = &album
+	res.Strategies = result.StrategiesFor(album.ID)
+	p.logf("🎯 Found on Spotify: \"%s\" by %s (via %s)", album.Name, album.GetMainArtist(), strings.Join(res.Strategies, ", "))
+	return nil
+}
+
+// replaceAlbums returns albums with the ones in full replaced by their full
+// versions.
+func replaceAlbums(albums, full []spotify.Album) []spotify.Album {
+	byID := make(map[string]spotify.Album, len(full))
+	for _, album := range full {
+		byID[album.ID] = album
+	}
+
+	replaced := make([]spotify.Album, len(albums))
+	for i, album := range albums {
+		if fullAlbum, ok := byID[album.ID]; ok {
+			album = fullAlbum
+		}
+		replaced[i] = album
+	}
+	return replaced
+}
+
+func (p *Player) printRelease(release *musicbrainz.Release) {
+	if details := release.Details(); details != "" {
+		p.logf("📀 Found album: \"%s\" by %s (%s)", release.Title, release.GetMainArtist(), details)
+		return
+	}
+	p.logf("📀 Found album: \"%s\" by %s", release.Title, release.GetMainArtist())
+}
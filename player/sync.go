@@ -0,0 +1,275 @@
+package player
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"barcode-music-player/config"
+	"barcode-music-player/spotify"
+)
+
+// SyncScopes returns the Spotify scopes the configured sync needs.
+func SyncScopes(cfg *config.Config) []string {
+	var scopes []string
+	if cfg.SyncLibrary {
+		scopes = append(scopes, spotify.ScopeLibraryModify)
+	}
+	if cfg.SyncPlaylistEnabled || cfg.SyncRecentEnabled {
+		scopes = append(scopes, spotify.ScopePlaylistReadPrivate, spotify.ScopePlaylistModifyPrivate)
+	}
+	return scopes
+}
+
+// sync saves a played album to the library and adds it to the managed
+// playlists. Failing to sync is only a warning: the album is playing.
+func (p *Player) sync(ctx context.Context, albumURI string) {
+	scopes := SyncScopes(p.cfg)
+	if len(scopes) == 0 {
+		return
+	}
+
+	if missing := p.spotify.MissingScopes(scopes...); len(missing) > 0 {
+		p.logf("⚠️  Warning: Not syncing, the Spotify login lacks %s: run 'barcode-music-player login' again", strings.Join(missing, ", "))
+		return
+	}
+
+	albumID, ok := spotify.ParseAlbumLink(albumURI)
+	if !ok {
+		return
+	}
+
+	if p.cfg.SyncLibrary {
+		if err := p.spotify.SaveAlbums(ctx, []string{albumID}); err != nil {
+			p.logf("⚠️  Warning: Could not save the album to your library: %v", err)
+		} else {
+			p.logf("💚 Saved to your Spotify library")
+		}
+	}
+
+	if !p.cfg.SyncPlaylistEnabled && !p.cfg.SyncRecentEnabled {
+		return
+	}
+
+	tracks, err := p.spotify.GetAlbumTracks(ctx, albumID)
+	if err != nil {
+		p.logf("⚠️  Warning: Could not get the album's tracks for the playlists: %v", err)
+		return
+	}
+	uris := make([]string, 0, len(tracks))
+	for _, track := range tracks {
+		uris = append(uris, track.URI)
+	}
+
+	if p.cfg.SyncPlaylistEnabled {
+		if err := p.syncCollectionPlaylist(ctx, albumURI, uris); err != nil {
+			p.logf("⚠️  Warning: Could not add the album to \"%s\": %v", p.cfg.SyncPlaylistName, err)
+		}
+	}
+	if p.cfg.SyncRecentEnabled {
+		if err := p.syncRecentPlaylist(ctx, albumURI, uris); err != nil {
+			p.logf("⚠️  Warning: Could not add the album to \"%s\": %v", p.cfg.SyncRecentName, err)
+		}
+	}
+}
+
+// playlistAlbums are the albums in a playlist as of a snapshot.
+type playlistAlbums struct {
+	snapshot string
+	albums   map[string]bool
+}
+
+// albumsIn returns the albums in a playlist. They are only read again when
+// the playlist's snapshot ID changed, so large playlists aren't downloaded on
+// every scan.
+func (p *Player) albumsIn(ctx context.Context, id string) (*playlistAlbums, error) {
+	snapshot, err := p.spotify.GetPlaylistSnapshot(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cached, ok := p.playlistAlbums[id]; ok && cached.snapshot == snapshot {
+		return cached, nil
+	}
+
+	items, err := p.spotify.GetPlaylistItems(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := &playlistAlbums{snapshot: snapshot, albums: make(map[string]bool)}
+	for _, item := range items {
+		if item.Track != nil && item.Track.Album != nil {
+			cached.albums[item.Track.Album.URI] = true
+		}
+	}
+	p.playlistAlbums[id] = cached
+	return cached, nil
+}
+
+// syncCollectionPlaylist appends an album to the collection playlist, unless
+// it is already in it.
+func (p *Player) syncCollectionPlaylist(ctx context.Context, albumURI string, uris []string) error {
+	id, err := p.managedPlaylist(ctx, p.cfg.SyncPlaylistName, "Albums scanned with barcode-music-player")
+	if err != nil {
+		return err
+	}
+
+	cached, err := p.albumsIn(ctx, id)
+	if err != nil {
+		return err
+	}
+	if cached.albums[albumURI] {
+		return nil
+	}
+
+	snapshot, err := p.spotify.AddPlaylistItems(ctx, id, uris, -1)
+	if err != nil {
+		return err
+	}
+	cached.albums[albumURI] = true
+	cached.snapshot = snapshot
+
+	p.logf("📚 Added to \"%s\"", p.cfg.SyncPlaylistName)
+	return nil
+}
+
+// syncRecentPlaylist moves an album to the top of the recently scanned
+// playlist, and removes the albums beyond SYNC_RECENT_SIZE.
+func (p *Player) syncRecentPlaylist(ctx context.Context, albumURI string, uris []string) error {
+	id, err := p.managedPlaylist(ctx, p.cfg.SyncRecentName, fmt.Sprintf("The last %d albums scanned with barcode-music-player", p.cfg.SyncRecentSize))
+	if err != nil {
+		return err
+	}
+
+	items, err := p.spotify.GetPlaylistItems(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// The albums after the scanned one, most recent first
+	var albums []string
+	for _, item := range items {
+		if item.Track == nil || item.Track.Album == nil {
+			continue
+		}
+		uri := item.Track.Album.URI
+		if uri != albumURI && !containsString(albums, uri) {
+			albums = append(albums, uri)
+		}
+	}
+
+	remove := albumTracks(items, albumURI)
+	if len(albums) >= p.cfg.SyncRecentSize {
+		for _, album := range albums[p.cfg.SyncRecentSize-1:] {
+			remove = append(remove, albumTracks(items, album)...)
+		}
+	}
+
+	if len(remove) > 0 {
+		if err := p.spotify.RemovePlaylistItems(ctx, id, remove); err != nil {
+			return err
+		}
+	}
+	if _, err := p.spotify.AddPlaylistItems(ctx, id, uris, 0); err != nil {
+		return err
+	}
+
+	p.logf("🕒 Added to \"%s\"", p.cfg.SyncRecentName)
+	return nil
+}
+
+// AddToPlaylist appends albums to the user's playlist called name, creating
+// it if needed. Albums already in the playlist are skipped, so adding the same
+// albums again is harmless. It returns how many albums were added.
+func (p *Player) AddToPlaylist(ctx context.Context, name string, albumURIs []string) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id, err := p.managedPlaylist(ctx, name, "Albums imported with barcode-music-player")
+	if err != nil {
+		return 0, err
+	}
+
+	cached, err := p.albumsIn(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	var uris []string
+	var added []string
+	for _, albumURI := range albumURIs {
+		albumID, ok := spotify.ParseAlbumLink(albumURI)
+		if !ok || cached.albums[albumURI] || containsString(added, albumURI) {
+			continue
+		}
+
+		tracks, err := p.spotify.GetAlbumTracks(ctx, albumID)
+		if err != nil {
+			return 0, err
+		}
+		for _, track := range tracks {
+			uris = append(uris, track.URI)
+		}
+		added = append(added, albumURI)
+	}
+
+	if len(uris) == 0 {
+		return 0, nil
+	}
+	snapshot, err := p.spotify.AddPlaylistItems(ctx, id, uris, -1)
+	if err != nil {
+		return 0, err
+	}
+	for _, albumURI := range added {
+		cached.albums[albumURI] = true
+	}
+	cached.snapshot = snapshot
+	return len(added), nil
+}
+
+// managedPlaylist returns the ID of the user's playlist called name, creating
+// it if needed. IDs are cached until the player is reloaded.
+func (p *Player) managedPlaylist(ctx context.Context, name, description string) (string, error) {
+	if id, ok := p.playlists[name]; ok {
+		return id, nil
+	}
+
+	user, err := p.spotify.GetCurrentUser(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	playlist, err := p.spotify.FindPlaylist(ctx, name, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if playlist == nil {
+		if playlist, err = p.spotify.CreatePlaylist(ctx, user.ID, name, description); err != nil {
+			return "", err
+		}
+		p.logf("🆕 Created the playlist \"%s\"", name)
+	}
+
+	p.playlists[name] = playlist.ID
+	return playlist.ID, nil
+}
+
+// albumTracks returns the URIs of a playlist's tracks from an album.
+func albumTracks(items []spotify.PlaylistItem, albumURI string) []string {
+	var uris []string
+	for _, item := range items {
+		if item.Track != nil && item.Track.Album != nil && item.Track.Album.URI == albumURI {
+			uris = append(uris, item.Track.URI)
+		}
+	}
+	return uris
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package queue
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Entry is a scanned album whose tracks were appended to the Spotify queue.
+type Entry struct {
+	AlbumName string    `json:"album_name"`
+	Artist    string    `json:"artist"`
+	AlbumURI  string    `json:"album_uri"`
+	TrackURIs []string  `json:"track_uris"`
+	QueuedAt  time.Time `json:"queued_at"`
+}
+
+func (e *Entry) contains(trackURI string) bool {
+	for _, uri := range e.TrackURIs {
+		if uri == trackURI {
+			return true
+		}
+	}
+	return false
+}
+
+// maxPending is how long an album can wait in the queue without playing before
+// it is assumed to have been skipped or replaced.
+const maxPending = 12 * time.Hour
+
+// Queue mirrors the albums we have added to the Spotify queue, so we can show
+// which of them are still pending. Spotify's own queue endpoint only exposes
+// individual tracks.
+type Queue struct {
+	mu      sync.Mutex
+	entries []Entry
+	// reached is set once a queued album has been seen playing
+	reached bool
+}
+
+func New() *Queue {
+	return &Queue{}
+}
+
+func (q *Queue) Add(entry Entry) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.entries = append(q.entries, entry)
+}
+
+// Sync drops albums that have finished playing, given the track that is
+// currently playing, and returns the remaining entries. The first entry is
+// the one playing if playing is true.
+//
+// Once the queue has been reached, a track from none of the albums means
+// playback has left the queue: it finished, or something else was played,
+// and every entry is dropped. Albums that never started playing expire after
+// maxPending.
+func (q *Queue) Sync(currentTrackURI string) (entries []Entry, playing bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i := range q.entries {
+		if q.entries[i].contains(currentTrackURI) {
+			q.entries = q.entries[i:]
+			q.reached = true
+			playing = true
+			break
+		}
+	}
+
+	if !playing {
+		// Without a current track, such as when no device is active, there
+		// is no telling where playback is
+		if q.reached && currentTrackURI != "" {
+			q.entries = nil
+			q.reached = false
+		}
+
+		cutoff := time.Now().Add(-maxPending)
+		for len(q.entries) > 0 && q.entries[0].QueuedAt.Before(cutoff) {
+			q.entries = q.entries[1:]
+		}
+	}
+
+	entries = make([]Entry, len(q.entries))
+	for i, entry := range q.entries {
+		entry.TrackURIs = slices.Clone(entry.TrackURIs)
+		entries[i] = entry
+	}
+	return entries, playing
+}
+
+func (q *Queue) Clear() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.entries = nil
+	q.reached = false
+}
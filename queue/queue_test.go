@@ -0,0 +1,127 @@
+package queue
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func entry(album string, tracks ...string) Entry {
+	return Entry{AlbumName: album, AlbumURI: "spotify:album:" + album, TrackURIs: tracks, QueuedAt: time.Now()}
+}
+
+func albumNames(entries []Entry) []string {
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.AlbumName)
+	}
+	return names
+}
+
+func TestSync(t *testing.T) {
+	tests := []struct {
+		name        string
+		tracks      []string
+		want        []string
+		wantPlaying bool
+	}{
+		{"nothing playing", []string{""}, []string{"a", "b", "c"}, false},
+		{"before the queue", []string{"other"}, []string{"a", "b", "c"}, false},
+		{"first album", []string{"a1"}, []string{"a", "b", "c"}, true},
+		{"second album", []string{"b2"}, []string{"b", "c"}, true},
+		{"last album", []string{"a1", "c1"}, []string{"c"}, true},
+		{"left the queue", []string{"b1", "other"}, nil, false},
+		{"paused after reaching", []string{"b1", ""}, []string{"b", "c"}, false},
+		{"finished album played again", []string{"c1", "a1"}, nil, false},
+	}
+
+	for _, tt := range tests {
+		q := New()
+		q.Add(entry("a", "a1", "a2"))
+		q.Add(entry("b", "b1", "b2"))
+		q.Add(entry("c", "c1"))
+
+		var entries []Entry
+		var playing bool
+		for _, track := range tt.tracks {
+			entries, playing = q.Sync(track)
+		}
+
+		if got := albumNames(entries); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: entries = %v, want %v", tt.name, got, tt.want)
+		}
+		if playing != tt.wantPlaying {
+			t.Errorf("%s: playing = %v, want %v", tt.name, playing, tt.wantPlaying)
+		}
+	}
+}
+
+func TestSyncAddAfterReached(t *testing.T) {
+	q := New()
+	q.Add(entry("a", "a1"))
+	q.Sync("a1")
+	q.Add(entry("b", "b1"))
+
+	entries, playing := q.Sync("a1")
+	if got, want := albumNames(entries), []string{"a", "b"}; !slices.Equal(got, want) || !playing {
+		t.Errorf("entries = %v, playing = %v, want %v playing", got, playing, want)
+	}
+
+	entries, playing = q.Sync("b1")
+	if got, want := albumNames(entries), []string{"b"}; !slices.Equal(got, want) || !playing {
+		t.Errorf("entries = %v, playing = %v, want %v playing", got, playing, want)
+	}
+}
+
+func TestSyncExpiresStaleEntries(t *testing.T) {
+	q := New()
+	stale := entry("a", "a1")
+	stale.QueuedAt = time.Now().Add(-maxPending - time.Minute)
+	q.Add(stale)
+	q.Add(entry("b", "b1"))
+
+	entries, _ := q.Sync("other")
+	if got, want := albumNames(entries), []string{"b"}; !slices.Equal(got, want) {
+		t.Errorf("entries = %v, want %v", got, want)
+	}
+
+	// An album that is playing doesn't expire
+	q = New()
+	q.Add(stale)
+	entries, playing := q.Sync("a1")
+	if got, want := albumNames(entries), []string{"a"}; !slices.Equal(got, want) || !playing {
+		t.Errorf("entries = %v, playing = %v, want %v playing", got, playing, want)
+	}
+}
+
+func TestSyncReturnsSnapshot(t *testing.T) {
+	q := New()
+	q.Add(entry("a", "a1"))
+	q.Add(entry("b", "b1"))
+
+	entries, _ := q.Sync("")
+	entries[0].AlbumName = "changed"
+	entries[1].TrackURIs[0] = "x1"
+
+	entries, _ = q.Sync("")
+	if got, want := albumNames(entries), []string{"a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("entries = %v, want %v", got, want)
+	}
+	if got := entries[1].TrackURIs[0]; got != "b1" {
+		t.Errorf("track = %s, want b1", got)
+	}
+}
+
+func TestClear(t *testing.T) {
+	q := New()
+	q.Add(entry("a", "a1"))
+	q.Sync("a1")
+	q.Clear()
+	q.Add(entry("b", "b1"))
+
+	// The queue hasn't been reached again, so another track keeps the entry
+	entries, _ := q.Sync("other")
+	if got, want := albumNames(entries), []string{"b"}; !slices.Equal(got, want) {
+		t.Errorf("entries = %v, want %v", got, want)
+	}
+}
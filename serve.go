@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"barcode-music-player/input"
+	"barcode-music-player/player"
+	"barcode-music-player/systemd"
+)
+
+// cancelTimeout is how long shutdown waits for a cancelled scan to return.
+const cancelTimeout = 2 * time.Second
+
+// runServe runs the player without a terminal until SIGINT or SIGTERM. SIGHUP
+// reloads the configuration and restarts the inputs and the API. Readiness,
+// reloads and shutdown are reported to systemd when run as a Type=notify
+// service.
+func runServe(args []string) error {
+	f := newFlags("serve", "")
+	if err := f.parse(args, 0); err != nil {
+		return err
+	}
+
+	fmt.Println("🎵 Barcode Music Player")
+	fmt.Println("=====================")
+
+	if err := f.loadConfig(); err != nil {
+		return err
+	}
+
+	// There is no one to complete the OAuth flow in a browser, so this needs
+	// a stored token
+	p, err := newPlayer(os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	if interval := systemd.WatchdogInterval(); interval > 0 {
+		go watchdog(ctx, interval)
+	}
+
+	// Scans outlive the services so that shutdown can let them finish
+	scans, cancelScans := context.WithCancel(context.Background())
+	defer cancelScans()
+
+	for {
+		// Nobody is at the terminal in serve mode
+		cfg.InputStdinEnabled = false
+
+		sources := newInputs(cfg)
+		if len(sources) == 0 && !cfg.APIEnabled {
+			return fail(exitConfig, fmt.Errorf("no input sources enabled"))
+		}
+
+		runCtx, cancel := context.WithCancel(ctx)
+		done := startServices(runCtx, scans, p, sources)
+
+		notify(systemd.Ready)
+		fmt.Println("✅ Ready to scan barcodes")
+		if cfg.DryRun {
+			fmt.Println("🧪 Dry run: scans are resolved but nothing is played")
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Println("🛑 Shutting down...")
+			notify(systemd.Stopping)
+			cancel()
+			shutdown(p, done, cancelScans)
+			return nil
+
+		case <-hangup:
+			fmt.Println("🔄 Reloading configuration...")
+			notify(systemd.Reloading)
+
+			// Stop taking scans while the configuration changes
+			cancel()
+			if !waitFor(done, cfg.ShutdownTimeout) {
+				fmt.Println("⚠️  Warning: Inputs are still stopping")
+			}
+
+			newCfg, err := f.reloadConfig()
+			if err != nil {
+				fmt.Printf("❌ Error: keeping the old configuration: %v\n", err)
+				continue
+			}
+
+			if err := p.Reload(newCfg); err != nil {
+				fmt.Printf("❌ Error: keeping the old configuration: %v\n", err)
+				continue
+			}
+			cfg = newCfg
+		}
+	}
+}
+
+// startServices runs the inputs and the API until ctx is cancelled. Scans run
+// under scans. The returned channel is closed once they have stopped and the
+// scan in progress has finished.
+func startServices(ctx, scans context.Context, p *player.Player, sources []input.Input) <-chan struct{} {
+	var wg sync.WaitGroup
+
+	if cfg.APIEnabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runAPI(ctx, scans, cfg, p)
+		}()
+	}
+
+	if len(sources) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for event := range input.NewMultiplexer(cfg.ScanDebounce, sources...).Run(ctx) {
+				if _, err := p.Scan(scans, event); err != nil {
+					fmt.Printf("❌ Error: %v\n", err)
+				}
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	return done
+}
+
+// shutdown waits for the scan in progress and saves state. Scans that take
+// longer than SHUTDOWN_TIMEOUT are cancelled, which aborts their MusicBrainz
+// and Spotify requests.
+func shutdown(p *player.Player, done <-chan struct{}, cancelScans context.CancelFunc) {
+	deadline := time.Now().Add(cfg.ShutdownTimeout)
+
+	if !waitFor(done, cfg.ShutdownTimeout) || !p.Wait(time.Until(deadline)) {
+		fmt.Println("⚠️  Warning: Cancelling the scan in progress")
+		cancelScans()
+		if !waitFor(done, cancelTimeout) || !p.Wait(cancelTimeout) {
+			fmt.Println("⚠️  Warning: Gave up waiting for the scan in progress")
+		}
+	}
+
+	if err := spotifyClient.SaveToken(); err != nil {
+		fmt.Printf("⚠️  Warning: Failed to save token: %v\n", err)
+	}
+
+	fmt.Println("Goodbye! 👋")
+}
+
+func waitFor(done <-chan struct{}, timeout time.Duration) bool {
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func watchdog(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			notify(systemd.Watchdog)
+		}
+	}
+}
+
+func notify(state string) {
+	if err := systemd.Notify(state); err != nil {
+		fmt.Printf("⚠️  Warning: Could not notify systemd: %v\n", err)
+	}
+}
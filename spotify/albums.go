@@ -0,0 +1,122 @@
+package spotify
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// GetAlbum fetches a full album, including all of its tracks.
+func (c *Client) GetAlbum(ctx context.Context, albumID string) (*Album, error) {
+	params := url.Values{}
+	c.addMarket(params)
+
+	var album Album
+	endpoint := fmt.Sprintf("https://api.spotify.com/v1/albums/%s?%s", url.PathEscape(albumID), params.Encode())
+	if err := c.get(ctx, endpoint, &album, "album"); err != nil {
+		return nil, fmt.Errorf("failed to get album %s: %w", albumID, err)
+	}
+
+	// The album only embeds the first page of tracks
+	if album.Tracks.Next != "" {
+		rest, err := getPaged[Track](ctx, c, album.Tracks.Next, "", "album tracks", 0)
+		if err != nil {
+			return nil, err
+		}
+		album.Tracks.Items = append(album.Tracks.Items, rest...)
+		album.Tracks.Next = ""
+	}
+
+	if !album.IsPlayable() {
+		return &album, fmt.Errorf("%w (%s): \"%s\" by %s", ErrRegionRestricted, c.Market, album.Name, album.GetMainArtist())
+	}
+
+	return &album, nil
+}
+
+// GetAlbums fetches full albums, including their first page of tracks.
+// Unknown IDs are skipped.
+func (c *Client) GetAlbums(ctx context.Context, albumIDs []string) ([]Album, error) {
+	var albums []Album
+
+	// The endpoint accepts at most 20 IDs per request
+	for start := 0; start < len(albumIDs); start += 20 {
+		end := min(start+20, len(albumIDs))
+
+		params := url.Values{}
+		params.Add("ids", strings.Join(albumIDs[start:end], ","))
+		c.addMarket(params)
+
+		var albumsResp struct {
+			Albums []*Album `json:"albums"`
+		}
+		if err := c.get(ctx, "https://api.spotify.com/v1/albums?"+params.Encode(), &albumsResp, "albums"); err != nil {
+			return nil, err
+		}
+
+		// Unknown IDs come back as null entries
+		for _, album := range albumsResp.Albums {
+			if album != nil {
+				albums = append(albums, *album)
+			}
+		}
+	}
+
+	return albums, nil
+}
+
+// GetAlbumTracks returns all tracks of an album in disc order.
+func (c *Client) GetAlbumTracks(ctx context.Context, albumID string) ([]Track, error) {
+	params := url.Values{}
+	params.Add("limit", "50")
+	c.addMarket(params)
+
+	endpoint := fmt.Sprintf("https://api.spotify.com/v1/albums/%s/tracks?%s", url.PathEscape(albumID), params.Encode())
+	return getPaged[Track](ctx, c, endpoint, "", "album tracks", 0)
+}
+
+// GetArtistAlbums returns an artist's albums, singles and compilations.
+func (c *Client) GetArtistAlbums(ctx context.Context, artistID string) ([]Album, error) {
+	params := url.Values{}
+	params.Add("include_groups", "album,single,compilation")
+	params.Add("limit", "50")
+	c.addMarket(params)
+
+	endpoint := fmt.Sprintf("https://api.spotify.com/v1/artists/%s/albums?%s", url.PathEscape(artistID), params.Encode())
+	return getPaged[Album](ctx, c, endpoint, "", "artist albums", 0)
+}
+
+// DiscStart returns the zero-based position of the first track of disc among
+// an album's tracks.
+func DiscStart(tracks []Track, disc int) (int, bool) {
+	for i, track := range tracks {
+		if track.DiscNumber == disc {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// ParseAlbumLink extracts the album ID from an open.spotify.com album link or
+// a spotify:album: URI.
+func ParseAlbumLink(link string) (string, bool) {
+	if id, ok := strings.CutPrefix(link, "spotify:album:"); ok {
+		return id, id != ""
+	}
+
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Host != "open.spotify.com" {
+		return "", false
+	}
+
+	// Paths look like /album/<id>, optionally with a locale prefix (/intl-de/album/<id>)
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	for i := 0; i < len(parts)-1; i++ {
+		if parts[i] == "album" && parts[i+1] != "" {
+			return parts[i+1], true
+		}
+	}
+
+	return "", false
+}
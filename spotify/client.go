@@ -2,16 +2,58 @@ package spotify
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
+// Playback errors, for telling apart why an album couldn't be played.
+var (
+	// ErrNoDevice is returned when Spotify isn't open on any device
+	ErrNoDevice = errors.New("no Spotify devices found")
+	// ErrDeviceNotFound is returned when the requested device, or the one
+	// playback was sent to, isn't available
+	ErrDeviceNotFound = errors.New("device not found or not available")
+	// ErrPremiumRequired is returned when the account can't control playback
+	ErrPremiumRequired = errors.New("playback failed - you need Spotify Premium to control playback remotely")
+	// ErrInsufficientScope is returned when the login doesn't grant a
+	// permission a request needs
+	ErrInsufficientScope = errors.New("the Spotify login doesn't grant this permission, run 'barcode-music-player login' again")
+)
+
+// Scopes the app asks for when logging in.
+const (
+	ScopeReadPlaybackState     = "user-read-playback-state"
+	ScopeModifyPlaybackState   = "user-modify-playback-state"
+	ScopeReadPrivate           = "user-read-private"
+	ScopeLibraryModify         = "user-library-modify"
+	ScopePlaylistReadPrivate   = "playlist-read-private"
+	ScopePlaylistModifyPrivate = "playlist-modify-private"
+)
+
+// Scopes are all the scopes requested by GetAuthURL.
+var Scopes = []string{
+	ScopeReadPlaybackState, ScopeModifyPlaybackState, ScopeReadPrivate,
+	ScopeLibraryModify, ScopePlaylistReadPrivate, ScopePlaylistModifyPrivate,
+}
+
+// legacyScope is what logins stored before the granted scope was recorded
+// were asked for.
+const legacyScope = ScopeReadPlaybackState + " " + ScopeModifyPlaybackState + " " + ScopeReadPrivate
+
+// deviceWakeUpDelay is how long to wait before retrying playback on a device
+// that playback was just transferred to.
+const deviceWakeUpDelay = 2 * time.Second
+
 type Client struct {
 	ClientID     string
 	ClientSecret string
@@ -19,14 +61,32 @@ type Client struct {
 	AccessToken  string
 	RefreshToken string
 	ExpiresAt    time.Time
-	HTTPClient   *http.Client
+	// Scope lists the granted scopes, separated by spaces
+	Scope      string
+	HTTPClient *http.Client
+
+	// tokenMu guards the tokens, which are refreshed as they expire
+	tokenMu sync.Mutex
+
+	// Market is the country code passed to search and album requests, so
+	// results are playable for the user
+	Market string
+
+	// Output receives progress messages, standard output if nil
+	Output io.Writer
 }
 
 type Album struct {
-	ID     string `json:"id"`
-	Name   string `json:"name"`
-	URI    string `json:"uri"`
-	Images []struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	URI         string `json:"uri"`
+	AlbumType   string `json:"album_type"`
+	TotalTracks int    `json:"total_tracks"`
+	ReleaseDate string `json:"release_date"`
+	// AvailableMarkets is only returned when no market is requested
+	AvailableMarkets []string      `json:"available_markets"`
+	Restrictions     *Restrictions `json:"restrictions"`
+	Images           []struct {
 		URL    string `json:"url"`
 		Height int    `json:"height"`
 		Width  int    `json:"width"`
@@ -35,6 +95,26 @@ type Album struct {
 		ID   string `json:"id"`
 		Name string `json:"name"`
 	} `json:"artists"`
+	// Tracks is only filled in when fetching full albums, not in search results
+	Tracks Page[Track] `json:"tracks"`
+}
+
+type Track struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	URI         string `json:"uri"`
+	DiscNumber  int    `json:"disc_number"`
+	TrackNumber int    `json:"track_number"`
+	DurationMS  int    `json:"duration_ms"`
+	Explicit    bool   `json:"explicit"`
+	// IsPlayable is only returned when a market is requested
+	IsPlayable *bool `json:"is_playable"`
+	Artists    []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"artists"`
+	// Album is not returned for tracks listed as part of an album
+	Album *Album `json:"album,omitempty"`
 }
 
 type Device struct {
@@ -50,13 +130,6 @@ type DevicesResponse struct {
 	Devices []Device `json:"devices"`
 }
 
-type SearchResponse struct {
-	Albums struct {
-		Items []Album `json:"items"`
-		Total int     `json:"total"`
-	} `json:"albums"`
-}
-
 type TokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	TokenType    string `json:"token_type"`
@@ -69,6 +142,7 @@ type StoredToken struct {
 	AccessToken  string    `json:"access_token"`
 	RefreshToken string    `json:"refresh_token"`
 	ExpiresAt    time.Time `json:"expires_at"`
+	Scope        string    `json:"scope,omitempty"`
 }
 
 func NewClient(clientID, clientSecret, redirectURI string) *Client {
@@ -82,6 +156,15 @@ func NewClient(clientID, clientSecret, redirectURI string) *Client {
 	}
 }
 
+// printf writes a progress message to the output.
+func (c *Client) printf(format string, args ...interface{}) {
+	out := c.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	fmt.Fprintf(out, format, args...)
+}
+
 func (c *Client) getTokenFilePath() string {
 	homeDir, _ := os.UserHomeDir()
 	return filepath.Join(homeDir, ".barcode-music-player-token.json")
@@ -100,25 +183,47 @@ func (c *Client) LoadStoredToken() bool {
 		return false
 	}
 
-	// Check if token is still valid (with 5 minute buffer)
-	if time.Now().Add(5 * time.Minute).After(stored.ExpiresAt) {
-		return false
-	}
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
 
 	c.AccessToken = stored.AccessToken
 	c.RefreshToken = stored.RefreshToken
 	c.ExpiresAt = stored.ExpiresAt
+	c.Scope = stored.Scope
+	if c.Scope == "" {
+		c.Scope = legacyScope
+	}
+
+	// Refresh an expired token (with 5 minute buffer)
+	if time.Now().Add(tokenRefreshMargin).After(stored.ExpiresAt) {
+		if stored.RefreshToken == "" {
+			return false
+		}
+		if err := c.refreshAccessToken(context.Background()); err != nil {
+			c.printf("⚠️  Warning: Could not refresh stored token: %v\n", err)
+			c.AccessToken = ""
+			return false
+		}
+	}
 
 	return true
 }
 
 func (c *Client) SaveToken() error {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	return c.saveToken()
+}
+
+func (c *Client) saveToken() error {
 	tokenFile := c.getTokenFilePath()
 
 	stored := StoredToken{
 		AccessToken:  c.AccessToken,
 		RefreshToken: c.RefreshToken,
 		ExpiresAt:    c.ExpiresAt,
+		Scope:        c.Scope,
 	}
 
 	data, err := json.MarshalIndent(stored, "", "  ")
@@ -129,70 +234,61 @@ func (c *Client) SaveToken() error {
 	return os.WriteFile(tokenFile, data, 0600)
 }
 
+// DeleteStoredToken forgets the tokens and removes the stored token file.
+func (c *Client) DeleteStoredToken() error {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	c.AccessToken = ""
+	c.RefreshToken = ""
+	c.ExpiresAt = time.Time{}
+	c.Scope = ""
+
+	if err := os.Remove(c.getTokenFilePath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
 func (c *Client) GetAuthURL() string {
 	params := url.Values{}
 	params.Add("client_id", c.ClientID)
 	params.Add("response_type", "code")
 	params.Add("redirect_uri", c.RedirectURI)
-	params.Add("scope", "user-read-playback-state user-modify-playback-state")
+	params.Add("scope", strings.Join(Scopes, " "))
 
 	return "https://accounts.spotify.com/authorize?" + params.Encode()
 }
 
-func (c *Client) ExchangeCodeForToken(code string) error {
+func (c *Client) ExchangeCodeForToken(ctx context.Context, code string) error {
 	data := url.Values{}
 	data.Set("grant_type", "authorization_code")
 	data.Set("code", code)
 	data.Set("redirect_uri", c.RedirectURI)
 
-	req, err := http.NewRequest("POST", "https://accounts.spotify.com/api/token", strings.NewReader(data.Encode()))
-	if err != nil {
-		return fmt.Errorf("failed to create token request: %w", err)
-	}
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.SetBasicAuth(c.ClientID, c.ClientSecret)
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
+	if err := c.requestToken(ctx, data); err != nil {
 		return fmt.Errorf("failed to exchange code for token: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("token exchange failed with status: %d", resp.StatusCode)
-	}
-
-	var tokenResp TokenResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
-		return fmt.Errorf("failed to decode token response: %w", err)
-	}
-
-	c.AccessToken = tokenResp.AccessToken
-	c.RefreshToken = tokenResp.RefreshToken
-	c.ExpiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
-
-	// Save token for future use
-	if err := c.SaveToken(); err != nil {
-		fmt.Printf("Warning: Failed to save token: %v\n", err)
-	}
 
 	return nil
 }
 
-func (c *Client) GetAvailableDevices() ([]Device, error) {
-	if c.AccessToken == "" {
+func (c *Client) GetAvailableDevices(ctx context.Context) ([]Device, error) {
+	if !c.Authenticated() {
 		return nil, fmt.Errorf("not authenticated - access token required")
 	}
 
-	req, err := http.NewRequest("GET", "https://api.spotify.com/v1/me/player/devices", nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.spotify.com/v1/me/player/devices", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create devices request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
+	c.authorize(req)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get devices: %w", err)
 	}
@@ -210,22 +306,27 @@ func (c *Client) GetAvailableDevices() ([]Device, error) {
 	return devicesResp.Devices, nil
 }
 
-func (c *Client) SetShuffle(state bool) error {
-	if c.AccessToken == "" {
+// SetShuffle sets shuffle on the given device, or on the active device if
+// deviceID is empty.
+func (c *Client) SetShuffle(ctx context.Context, state bool, deviceID string) error {
+	if !c.Authenticated() {
 		return fmt.Errorf("not authenticated - access token required")
 	}
 
 	params := url.Values{}
 	params.Add("state", fmt.Sprintf("%t", state))
+	if deviceID != "" {
+		params.Add("device_id", deviceID)
+	}
 
-	req, err := http.NewRequest("PUT", "https://api.spotify.com/v1/me/player/shuffle?"+params.Encode(), nil)
+	req, err := http.NewRequestWithContext(ctx, "PUT", "https://api.spotify.com/v1/me/player/shuffle?"+params.Encode(), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create shuffle request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
+	c.authorize(req)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to set shuffle: %w", err)
 	}
@@ -238,54 +339,29 @@ func (c *Client) SetShuffle(state bool) error {
 	return nil
 }
 
-func (c *Client) SearchAlbums(query string) ([]Album, error) {
-	if c.AccessToken == "" {
+// SearchTracks returns the best matching tracks for a query, which may use
+// field filters such as isrc:, track: and artist:.
+func (c *Client) SearchTracks(ctx context.Context, query string) ([]Track, error) {
+	if !c.Authenticated() {
 		return nil, fmt.Errorf("not authenticated - access token required")
 	}
 
-	// Try multiple search strategies
-	searchStrategies := []string{
-		query,                                   // Original query
-		strings.ReplaceAll(query, "album:", ""), // Remove album: prefix
-		strings.ReplaceAll(strings.ReplaceAll(query, "album:", ""), "artist:", ""), // Remove both prefixes
-	}
-
-	for i, searchQuery := range searchStrategies {
-		fmt.Printf("🔍 Search attempt %d: %s\n", i+1, searchQuery)
-
-		albums, err := c.performSearch(searchQuery)
-		if err != nil {
-			fmt.Printf("   ❌ Search failed: %v\n", err)
-			continue
-		}
-
-		if len(albums) > 0 {
-			fmt.Printf("   ✅ Found %d albums\n", len(albums))
-			return albums, nil
-		}
-
-		fmt.Printf("   ⚠️  No results\n")
-	}
-
-	return nil, fmt.Errorf("no albums found after trying multiple search strategies")
-}
-
-func (c *Client) performSearch(query string) ([]Album, error) {
 	params := url.Values{}
 	params.Add("q", query)
-	params.Add("type", "album")
-	params.Add("limit", "10")
+	params.Add("type", "track")
+	params.Add("limit", "5")
+	c.addMarket(params)
 
-	req, err := http.NewRequest("GET", "https://api.spotify.com/v1/search?"+params.Encode(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.spotify.com/v1/search?"+params.Encode(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create search request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
+	c.authorize(req)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search albums: %w", err)
+		return nil, fmt.Errorf("failed to search tracks: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -293,66 +369,101 @@ func (c *Client) performSearch(query string) ([]Album, error) {
 		return nil, fmt.Errorf("search request failed with status: %d", resp.StatusCode)
 	}
 
-	var searchResp SearchResponse
+	var searchResp struct {
+		Tracks struct {
+			Items []Track `json:"items"`
+		} `json:"tracks"`
+	}
 	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
 		return nil, fmt.Errorf("failed to decode search response: %w", err)
 	}
 
-	return searchResp.Albums.Items, nil
+	return searchResp.Tracks.Items, nil
 }
 
-func (c *Client) PlayAlbum(albumURI string) error {
-	if c.AccessToken == "" {
-		return fmt.Errorf("not authenticated - access token required")
+// PlayAlbum starts playing an album with the given options.
+func (c *Client) PlayAlbum(ctx context.Context, albumURI string, opts PlayOptions) error {
+	playData := map[string]interface{}{
+		"context_uri": albumURI,
+	}
+
+	if opts.TrackURI != "" {
+		playData["offset"] = map[string]interface{}{"uri": opts.TrackURI}
+	} else {
+		playData["offset"] = map[string]interface{}{"position": opts.Position}
 	}
 
-	// First, check for available devices
-	fmt.Println("🔍 Checking for available Spotify devices...")
-	devices, err := c.GetAvailableDevices()
+	return c.startPlayback(ctx, playData, opts)
+}
+
+// sendPlay sends a start playback request to a device. The caller must close
+// the response body.
+func (c *Client) sendPlay(ctx context.Context, deviceID string, jsonData []byte) (*http.Response, error) {
+	params := url.Values{}
+	params.Add("device_id", deviceID)
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", "https://api.spotify.com/v1/me/player/play?"+params.Encode(), bytes.NewReader(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to get available devices: %w", err)
+		return nil, fmt.Errorf("failed to create play request: %w", err)
 	}
 
-	if len(devices) == 0 {
-		return fmt.Errorf("no Spotify devices found. Please:\n" +
-			"1. Open Spotify on your computer, phone, or web browser\n" +
-			"2. Start playing any song to activate the device\n" +
-			"3. Try scanning the barcode again")
+	c.authorize(req)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start playback: %w", err)
 	}
 
-	// Find an active device or use the first available one
-	var activeDevice *Device
-	for _, device := range devices {
-		if device.IsActive {
-			activeDevice = &device
-			break
-		}
+	return resp, nil
+}
+
+// PlayTracks plays an ad-hoc list of tracks with the given options.
+func (c *Client) PlayTracks(ctx context.Context, trackURIs []string, opts PlayOptions) error {
+	playData := map[string]interface{}{
+		"uris": trackURIs,
 	}
 
-	if activeDevice == nil {
-		activeDevice = &devices[0]
-		fmt.Printf("🔄 No active device found, using: %s (%s)\n", activeDevice.Name, activeDevice.Type)
-	} else {
-		fmt.Printf("🎵 Using active device: %s (%s)\n", activeDevice.Name, activeDevice.Type)
+	if opts.TrackURI != "" {
+		playData["offset"] = map[string]interface{}{"uri": opts.TrackURI}
+	} else if opts.Position > 0 {
+		playData["offset"] = map[string]interface{}{"position": opts.Position}
 	}
 
-	// Disable shuffle to ensure album plays in order
-	fmt.Println("🔀 Disabling shuffle to play album in order...")
-	if err := c.SetShuffle(false); err != nil {
-		// Don't fail if shuffle can't be disabled, just warn
-		fmt.Printf("⚠️  Warning: Could not disable shuffle: %v\n", err)
+	return c.startPlayback(ctx, playData, opts)
+}
+
+func (c *Client) startPlayback(ctx context.Context, playData map[string]interface{}, opts PlayOptions) error {
+	if !c.Authenticated() {
+		return fmt.Errorf("not authenticated - access token required")
 	}
 
-	playData := map[string]interface{}{
-		"context_uri": albumURI,
-		"offset": map[string]interface{}{
-			"position": 0, // Start from the first track
-		},
+	activeDevice, err := c.ChooseDevice(ctx, opts.Device)
+	if err != nil {
+		return err
 	}
 
-	// If we're using a non-active device, specify it
+	switch {
+	case opts.Device != "":
+		c.printf("🎵 Using requested device: %s (%s)\n", activeDevice.Name, activeDevice.Type)
+	case activeDevice.IsActive:
+		c.printf("🎵 Using active device: %s (%s)\n", activeDevice.Name, activeDevice.Type)
+	default:
+		c.printf("🔄 No active device found, using: %s (%s)\n", activeDevice.Name, activeDevice.Type)
+	}
+
+	// Wake up an inactive device by transferring playback to it first
 	if !activeDevice.IsActive {
-		playData["device_id"] = activeDevice.ID
+		c.printf("📲 Transferring playback to %s...\n", activeDevice.Name)
+		if err := c.TransferPlayback(ctx, activeDevice.ID, false); err != nil {
+			c.printf("⚠️  Warning: Could not transfer playback: %v\n", err)
+		}
+	}
+
+	c.applyPlayOptions(ctx, activeDevice.ID, opts)
+
+	if opts.PositionMS > 0 {
+		playData["position_ms"] = opts.PositionMS
 	}
 
 	jsonData, err := json.Marshal(playData)
@@ -360,29 +471,39 @@ func (c *Client) PlayAlbum(albumURI string) error {
 		return fmt.Errorf("failed to marshal play data: %w", err)
 	}
 
-	req, err := http.NewRequest("PUT", "https://api.spotify.com/v1/me/player/play", bytes.NewBuffer(jsonData))
+	resp, err := c.sendPlay(ctx, activeDevice.ID, jsonData)
 	if err != nil {
-		return fmt.Errorf("failed to create play request: %w", err)
+		return err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
-	req.Header.Set("Content-Type", "application/json")
+	// A device that was just woken up may not accept commands yet
+	if resp.StatusCode == http.StatusNotFound && !activeDevice.IsActive {
+		resp.Body.Close()
+		c.printf("⏳ Device is still waking up, retrying...\n")
+		timer := time.NewTimer(deviceWakeUpDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
 
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to play album: %w", err)
+		resp, err = c.sendPlay(ctx, activeDevice.ID, jsonData)
+		if err != nil {
+			return err
+		}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return fmt.Errorf("device not found or not available. Please:\n" +
-			"1. Make sure Spotify is running on your device\n" +
-			"2. Play any song to activate the device\n" +
-			"3. Ensure you have Spotify Premium (required for playback control)")
+		return fmt.Errorf("%w. Please:\n"+
+			"1. Make sure Spotify is running on your device\n"+
+			"2. Play any song to activate the device\n"+
+			"3. Ensure you have Spotify Premium (required for playback control)", ErrDeviceNotFound)
 	}
 
 	if resp.StatusCode == http.StatusForbidden {
-		return fmt.Errorf("playback failed - you need Spotify Premium to control playback remotely")
+		return ErrPremiumRequired
 	}
 
 	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
@@ -392,9 +513,55 @@ func (c *Client) PlayAlbum(albumURI string) error {
 	return nil
 }
 
+// HasExplicitTracks reports whether any of the album's loaded tracks is explicit.
+func (a *Album) HasExplicitTracks() bool {
+	for _, track := range a.Tracks.Items {
+		if track.Explicit {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *Album) GetMainArtist() string {
 	if len(a.Artists) > 0 {
 		return a.Artists[0].Name
 	}
 	return "Unknown Artist"
 }
+
+func (c *Client) AddToQueue(ctx context.Context, uri string) error {
+	if !c.Authenticated() {
+		return fmt.Errorf("not authenticated - access token required")
+	}
+
+	params := url.Values{}
+	params.Add("uri", uri)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.spotify.com/v1/me/player/queue?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create queue request: %w", err)
+	}
+
+	c.authorize(req)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to add to queue: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("no active device to queue on - start playing something first")
+	}
+
+	if resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("queueing failed - you need Spotify Premium to control playback remotely")
+	}
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("queue request failed with status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package spotify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// roundTripper answers requests with a handler instead of sending them to
+// Spotify.
+type roundTripper struct {
+	handler http.HandlerFunc
+}
+
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorder := httptest.NewRecorder()
+	rt.handler(recorder, req)
+	return recorder.Result(), nil
+}
+
+func newTestClient(handler http.HandlerFunc) *Client {
+	c := NewClient("id", "secret", "http://127.0.0.1/callback")
+	c.AccessToken = "token"
+	c.ExpiresAt = time.Now().Add(time.Hour)
+	c.HTTPClient = &http.Client{Transport: roundTripper{handler}}
+	c.Output = io.Discard
+	return c
+}
+
+func TestPlayAlbumCancelledWhileDeviceWakesUp(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/me/player/devices":
+			io.WriteString(w, `{"devices": [{"id": "d1", "name": "Speaker", "is_active": false}]}`)
+		case "/v1/me/player/play":
+			// The device isn't awake yet; give up while waiting to retry
+			cancel()
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	start := time.Now()
+	err := c.PlayAlbum(ctx, "spotify:album:1", PlayOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed >= deviceWakeUpDelay {
+		t.Errorf("returned after %s, want before the %s wake-up delay", elapsed, deviceWakeUpDelay)
+	}
+}
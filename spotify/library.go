@@ -0,0 +1,151 @@
+package spotify
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxPlaylistItems is the most tracks a Spotify playlist can hold.
+const maxPlaylistItems = 10000
+
+type Playlist struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	URI    string `json:"uri"`
+	Public bool   `json:"public"`
+	Owner  struct {
+		ID string `json:"id"`
+	} `json:"owner"`
+}
+
+// PlaylistItem is a track in a playlist. Track is nil for tracks that are no
+// longer available.
+type PlaylistItem struct {
+	AddedAt time.Time `json:"added_at"`
+	Track   *Track    `json:"track"`
+}
+
+// SaveAlbums adds albums to the user's library. Albums already saved stay as
+// they are.
+func (c *Client) SaveAlbums(ctx context.Context, albumIDs []string) error {
+	// The endpoint accepts at most 20 IDs per request
+	for start := 0; start < len(albumIDs); start += 20 {
+		end := min(start+20, len(albumIDs))
+
+		params := url.Values{}
+		params.Add("ids", strings.Join(albumIDs[start:end], ","))
+		if err := c.send(ctx, "PUT", "https://api.spotify.com/v1/me/albums?"+params.Encode(), nil, nil, "save albums"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindPlaylist returns the user's playlist called name and owned by ownerID,
+// or nil if there is none.
+func (c *Client) FindPlaylist(ctx context.Context, name, ownerID string) (*Playlist, error) {
+	playlists, err := getPaged[Playlist](ctx, c, "https://api.spotify.com/v1/me/playlists?limit=50", "", "playlists", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, playlist := range playlists {
+		if playlist.Name == name && playlist.Owner.ID == ownerID {
+			return &playlist, nil
+		}
+	}
+	return nil, nil
+}
+
+// CreatePlaylist creates a private playlist for the user.
+func (c *Client) CreatePlaylist(ctx context.Context, userID, name, description string) (*Playlist, error) {
+	var playlist Playlist
+	endpoint := fmt.Sprintf("https://api.spotify.com/v1/users/%s/playlists", url.PathEscape(userID))
+	body := map[string]interface{}{
+		"name":        name,
+		"description": description,
+		"public":      false,
+	}
+	if err := c.send(ctx, "POST", endpoint, body, &playlist, "create playlist"); err != nil {
+		return nil, err
+	}
+	return &playlist, nil
+}
+
+// GetPlaylistItems returns the tracks of a playlist in order, with the album
+// of each track. Playlists with more than maxPlaylistItems tracks are an
+// error rather than cut short.
+func (c *Client) GetPlaylistItems(ctx context.Context, playlistID string) ([]PlaylistItem, error) {
+	params := url.Values{}
+	params.Add("limit", "100")
+	params.Add("fields", "items(added_at,track(id,name,uri,album(id,name,uri))),next,total")
+
+	endpoint := fmt.Sprintf("https://api.spotify.com/v1/playlists/%s/tracks?%s", url.PathEscape(playlistID), params.Encode())
+	items, err := getPaged[PlaylistItem](ctx, c, endpoint, "", "playlist items", maxPlaylistItems+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) > maxPlaylistItems {
+		return nil, fmt.Errorf("the playlist has more than %d tracks", maxPlaylistItems)
+	}
+	return items, nil
+}
+
+// GetPlaylistSnapshot returns the snapshot ID of a playlist, which changes
+// whenever the playlist does.
+func (c *Client) GetPlaylistSnapshot(ctx context.Context, playlistID string) (string, error) {
+	var playlist struct {
+		SnapshotID string `json:"snapshot_id"`
+	}
+	endpoint := fmt.Sprintf("https://api.spotify.com/v1/playlists/%s?fields=snapshot_id", url.PathEscape(playlistID))
+	if err := c.get(ctx, endpoint, &playlist, "get playlist"); err != nil {
+		return "", err
+	}
+	return playlist.SnapshotID, nil
+}
+
+// AddPlaylistItems inserts tracks into a playlist at position, or appends
+// them if position is negative. It returns the playlist's new snapshot ID.
+func (c *Client) AddPlaylistItems(ctx context.Context, playlistID string, uris []string, position int) (string, error) {
+	endpoint := fmt.Sprintf("https://api.spotify.com/v1/playlists/%s/tracks", url.PathEscape(playlistID))
+
+	var snapshot struct {
+		SnapshotID string `json:"snapshot_id"`
+	}
+
+	// The endpoint accepts at most 100 tracks per request
+	for start := 0; start < len(uris); start += 100 {
+		end := min(start+100, len(uris))
+
+		body := map[string]interface{}{"uris": uris[start:end]}
+		if position >= 0 {
+			body["position"] = position + start
+		}
+		if err := c.send(ctx, "POST", endpoint, body, &snapshot, "add playlist items"); err != nil {
+			return "", err
+		}
+	}
+	return snapshot.SnapshotID, nil
+}
+
+// RemovePlaylistItems removes every occurrence of tracks from a playlist.
+func (c *Client) RemovePlaylistItems(ctx context.Context, playlistID string, uris []string) error {
+	endpoint := fmt.Sprintf("https://api.spotify.com/v1/playlists/%s/tracks", url.PathEscape(playlistID))
+
+	// The endpoint accepts at most 100 tracks per request
+	for start := 0; start < len(uris); start += 100 {
+		end := min(start+100, len(uris))
+
+		tracks := make([]map[string]string, 0, end-start)
+		for _, uri := range uris[start:end] {
+			tracks = append(tracks, map[string]string{"uri": uri})
+		}
+		if err := c.send(ctx, "DELETE", endpoint, map[string]interface{}{"tracks": tracks}, nil, "remove playlist items"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
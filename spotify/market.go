@@ -0,0 +1,108 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrRegionRestricted is returned when an album exists on Spotify but can't be
+// played in the client's market.
+var ErrRegionRestricted = errors.New("album is not available in your market")
+
+type Restrictions struct {
+	Reason string `json:"reason"`
+}
+
+type User struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name"`
+	Country     string `json:"country"`
+	Product     string `json:"product"`
+}
+
+func (c *Client) GetCurrentUser(ctx context.Context) (*User, error) {
+	if !c.Authenticated() {
+		return nil, fmt.Errorf("not authenticated - access token required")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.spotify.com/v1/me", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create profile request: %w", err)
+	}
+
+	c.authorize(req)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("profile request failed with status: %d", resp.StatusCode)
+	}
+
+	var user User
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed to decode profile response: %w", err)
+	}
+
+	return &user, nil
+}
+
+// DetectMarket sets the client's market to the country of the user's account.
+func (c *Client) DetectMarket(ctx context.Context) error {
+	user, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if user.Country == "" {
+		return fmt.Errorf("account has no country, set SPOTIFY_MARKET instead")
+	}
+
+	c.Market = user.Country
+	return nil
+}
+
+// addMarket adds the client's market to request parameters, if known.
+func (c *Client) addMarket(params url.Values) {
+	if c.Market != "" {
+		params.Set("market", c.Market)
+	}
+}
+
+// IsPlayable reports whether the album can be played in the market it was
+// fetched for. Track playability is only known for albums fetched with their
+// tracks.
+func (a *Album) IsPlayable() bool {
+	if a.Restrictions != nil {
+		return false
+	}
+
+	if len(a.Tracks.Items) == 0 {
+		return true
+	}
+
+	for _, track := range a.Tracks.Items {
+		if track.IsPlayable == nil || *track.IsPlayable {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterPlayable returns the albums that are playable in the client's market.
+func FilterPlayable(albums []Album) []Album {
+	playable := albums[:0:0]
+	for _, album := range albums {
+		if album.IsPlayable() {
+			playable = append(playable, album)
+		}
+	}
+	return playable
+}
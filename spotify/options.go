@@ -0,0 +1,125 @@
+package spotify
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Repeat modes accepted by the Spotify API.
+const (
+	RepeatOff     = "off"
+	RepeatContext = "context"
+	RepeatTrack   = "track"
+)
+
+// PlayOptions control how playback starts. Zero values keep the behaviour of
+// playing in order from the first track on the active device.
+type PlayOptions struct {
+	Shuffle bool `json:"shuffle"`
+	// Repeat is one of the Repeat* modes; empty leaves it unchanged
+	Repeat string `json:"repeat,omitempty"`
+	// Position is the zero-based track to start at, unless TrackURI is set
+	Position   int    `json:"position,omitempty"`
+	TrackURI   string `json:"track_uri,omitempty"`
+	PositionMS int    `json:"position_ms,omitempty"`
+	// Volume is a percentage; nil leaves it unchanged
+	Volume *int `json:"volume,omitempty"`
+	// Device is a device ID or name; empty picks the active device
+	Device string `json:"device,omitempty"`
+}
+
+// applyPlayOptions sets shuffle, repeat and volume on the device before
+// playback starts. Failures only produce warnings.
+func (c *Client) applyPlayOptions(ctx context.Context, deviceID string, opts PlayOptions) {
+	if opts.Shuffle {
+		c.printf("🔀 Enabling shuffle...\n")
+	} else {
+		c.printf("🔀 Disabling shuffle to play album in order...\n")
+	}
+	if err := c.SetShuffle(ctx, opts.Shuffle, deviceID); err != nil {
+		c.printf("⚠️  Warning: Could not set shuffle: %v\n", err)
+	}
+
+	if opts.Repeat != "" {
+		c.printf("🔁 Setting repeat to %s...\n", opts.Repeat)
+		if err := c.SetRepeat(ctx, opts.Repeat, deviceID); err != nil {
+			c.printf("⚠️  Warning: Could not set repeat: %v\n", err)
+		}
+	}
+
+	if opts.Volume != nil {
+		c.printf("🔊 Setting volume to %d%%...\n", *opts.Volume)
+		if err := c.SetVolume(ctx, *opts.Volume, deviceID); err != nil {
+			c.printf("⚠️  Warning: Could not set volume: %v\n", err)
+		}
+	}
+}
+
+// SetRepeat sets the repeat mode on the given device, or on the active device
+// if deviceID is empty.
+func (c *Client) SetRepeat(ctx context.Context, mode, deviceID string) error {
+	params := url.Values{}
+	params.Add("state", mode)
+	if deviceID != "" {
+		params.Add("device_id", deviceID)
+	}
+
+	return c.sendPlayerCommand(ctx, "PUT", "repeat?"+params.Encode(), "set repeat")
+}
+
+// SetVolume sets the volume percentage on the given device, or on the active
+// device if deviceID is empty.
+func (c *Client) SetVolume(ctx context.Context, percent int, deviceID string) error {
+	params := url.Values{}
+	params.Add("volume_percent", fmt.Sprint(min(max(percent, 0), 100)))
+	if deviceID != "" {
+		params.Add("device_id", deviceID)
+	}
+
+	return c.sendPlayerCommand(ctx, "PUT", "volume?"+params.Encode(), "set volume")
+}
+
+// ChooseDevice returns the device playback would start on: the requested
+// device (an ID or name) if given, otherwise the active device or the first
+// available one.
+func (c *Client) ChooseDevice(ctx context.Context, idOrName string) (*Device, error) {
+	c.printf("🔍 Checking for available Spotify devices...\n")
+	devices, err := c.GetAvailableDevices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get available devices: %w", err)
+	}
+
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("%w. Please:\n"+
+			"1. Open Spotify on your computer, phone, or web browser\n"+
+			"2. Start playing any song to activate the device\n"+
+			"3. Try scanning the barcode again", ErrNoDevice)
+	}
+
+	if idOrName != "" {
+		device := findDevice(devices, idOrName)
+		if device == nil {
+			return nil, fmt.Errorf("%w: %q, make sure Spotify is open on it", ErrDeviceNotFound, idOrName)
+		}
+		return device, nil
+	}
+
+	for i := range devices {
+		if devices[i].IsActive {
+			return &devices[i], nil
+		}
+	}
+	return &devices[0], nil
+}
+
+// findDevice finds a device by ID or, case-insensitively, by name.
+func findDevice(devices []Device, idOrName string) *Device {
+	for i := range devices {
+		if devices[i].ID == idOrName || strings.EqualFold(devices[i].Name, idOrName) {
+			return &devices[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package spotify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxPagedItems caps how many items are fetched by following next links, so a
+// huge discography can't turn into dozens of requests.
+const maxPagedItems = 500
+
+// Page is a Spotify paging object.
+type Page[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next"`
+	Total int    `json:"total"`
+}
+
+// get performs an authenticated GET request and decodes the JSON response into
+// target. action names the request in error messages.
+func (c *Client) get(ctx context.Context, endpoint string, target interface{}, action string) error {
+	if !c.Authenticated() {
+		return fmt.Errorf("not authenticated - access token required")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", action, err)
+	}
+
+	c.authorize(req)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s request failed with status: %d", action, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", action, err)
+	}
+
+	return nil
+}
+
+// send performs an authenticated request with body, if not nil, as JSON and
+// decodes the response into target, if not nil. A 403 means the login lacks
+// a scope the request needs.
+func (c *Client) send(ctx context.Context, method, endpoint string, body, target interface{}, action string) error {
+	if !c.Authenticated() {
+		return fmt.Errorf("not authenticated - access token required")
+	}
+
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s data: %w", action, err)
+		}
+		reader = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", action, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	c.authorize(req)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("%s failed: %w", action, ErrInsufficientScope)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s request failed with status: %d", action, resp.StatusCode)
+	}
+
+	if target != nil {
+		if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+			return fmt.Errorf("failed to decode %s response: %w", action, err)
+		}
+	}
+
+	return nil
+}
+
+// getPaged fetches endpoint and follows its next links until maxItems items
+// have been collected (0 means maxPagedItems). If key is not empty, the page
+// is nested under that key, as in search responses.
+func getPaged[T any](ctx context.Context, c *Client, endpoint, key, action string, maxItems int) ([]T, error) {
+	if maxItems <= 0 {
+		maxItems = maxPagedItems
+	}
+
+	var items []T
+	for endpoint != "" && len(items) < maxItems {
+		var page *Page[T]
+
+		if key == "" {
+			page = &Page[T]{}
+			if err := c.get(ctx, endpoint, page, action); err != nil {
+				return nil, err
+			}
+		} else {
+			var wrapper map[string]*Page[T]
+			if err := c.get(ctx, endpoint, &wrapper, action); err != nil {
+				return nil, err
+			}
+			if page = wrapper[key]; page == nil {
+				break
+			}
+		}
+
+		items = append(items, page.Items...)
+		endpoint = page.Next
+	}
+
+	if len(items) > maxItems {
+		items = items[:maxItems]
+	}
+
+	return items, nil
+}
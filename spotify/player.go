@@ -0,0 +1,150 @@
+package spotify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type PlaybackContext struct {
+	Type string `json:"type"`
+	URI  string `json:"uri"`
+}
+
+type PlaybackState struct {
+	Device       Device           `json:"device"`
+	IsPlaying    bool             `json:"is_playing"`
+	ShuffleState bool             `json:"shuffle_state"`
+	RepeatState  string           `json:"repeat_state"`
+	ProgressMS   int              `json:"progress_ms"`
+	Context      *PlaybackContext `json:"context"`
+	Item         *Track           `json:"item"`
+}
+
+// IsPlayingContext reports whether uri is the album or playlist currently loaded.
+func (s *PlaybackState) IsPlayingContext(uri string) bool {
+	return s != nil && s.Context != nil && s.Context.URI == uri
+}
+
+// GetPlaybackState returns the current playback state, or nil if nothing is
+// playing on any device.
+func (c *Client) GetPlaybackState(ctx context.Context) (*PlaybackState, error) {
+	if !c.Authenticated() {
+		return nil, fmt.Errorf("not authenticated - access token required")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.spotify.com/v1/me/player", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create playback state request: %w", err)
+	}
+
+	c.authorize(req)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playback state: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("playback state request failed with status: %d", resp.StatusCode)
+	}
+
+	var state PlaybackState
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to decode playback state: %w", err)
+	}
+
+	return &state, nil
+}
+
+// TransferPlayback moves playback to a device, starting playback there only if
+// play is true.
+func (c *Client) TransferPlayback(ctx context.Context, deviceID string, play bool) error {
+	if !c.Authenticated() {
+		return fmt.Errorf("not authenticated - access token required")
+	}
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"device_ids": []string{deviceID},
+		"play":       play,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal transfer data: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", "https://api.spotify.com/v1/me/player", bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create transfer request: %w", err)
+	}
+
+	c.authorize(req)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to transfer playback: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("transfer failed - you need Spotify Premium to control playback remotely")
+	}
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("transfer request failed with status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (c *Client) Pause(ctx context.Context) error {
+	return c.sendPlayerCommand(ctx, "PUT", "pause", "pause")
+}
+
+func (c *Client) Resume(ctx context.Context) error {
+	return c.sendPlayerCommand(ctx, "PUT", "play", "resume")
+}
+
+func (c *Client) Next(ctx context.Context) error {
+	return c.sendPlayerCommand(ctx, "POST", "next", "skip to next track")
+}
+
+func (c *Client) Previous(ctx context.Context) error {
+	return c.sendPlayerCommand(ctx, "POST", "previous", "skip to previous track")
+}
+
+func (c *Client) sendPlayerCommand(ctx context.Context, method, endpoint, action string) error {
+	if !c.Authenticated() {
+		return fmt.Errorf("not authenticated - access token required")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, "https://api.spotify.com/v1/me/player/"+endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", action, err)
+	}
+
+	c.authorize(req)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("%s failed - you need Spotify Premium to control playback remotely", action)
+	}
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("%s request failed with status: %d", action, resp.StatusCode)
+	}
+
+	return nil
+}
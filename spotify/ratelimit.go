@@ -0,0 +1,61 @@
+package spotify
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Rate limiting: Spotify answers 429 with a Retry-After header when an app
+// makes too many requests in its rolling window.
+const (
+	// maxRateLimitRetries is how often a request is retried after a 429
+	maxRateLimitRetries = 3
+	// maxRetryAfter is the longest wait before retrying; longer waits fail
+	// the request instead
+	maxRetryAfter = 30 * time.Second
+)
+
+// do sends a request, waiting and retrying when Spotify rate limits it.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt == maxRateLimitRetries {
+			return resp, err
+		}
+
+		wait := retryAfter(resp.Header.Get("Retry-After"))
+		if wait > maxRetryAfter || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to retry request: %w", err)
+			}
+			req.Body = body
+		}
+
+		c.printf("⏳ Rate limited by Spotify, retrying in %s...\n", wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// retryAfter parses a Retry-After header given in seconds, defaulting to one
+// second.
+func retryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 1 {
+		return time.Second
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,352 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"barcode-music-player/normalize"
+)
+
+const (
+	searchPageSize   = 20
+	searchMaxResults = 40
+	// maxSearchQueries caps the queries of one SearchAlbums call, each of
+	// which may take two requests with paging
+	maxSearchQueries = 8
+)
+
+// Search strategies, in the order SearchAlbums runs them.
+const (
+	StrategyKeywords    = "keywords"
+	StrategyFielded     = "fielded"
+	StrategyYear        = "year"
+	StrategyTitleArtist = "title+artist-id"
+	StrategyDiscography = "discography"
+)
+
+// SearchRequest describes the album to look for. The first title and artist
+// are the release's own, any others are alternate names.
+type SearchRequest struct {
+	Titles   []string
+	Artists  []string
+	Year     int
+	Keywords []string
+	// Accept, if set, reports whether an album is good enough to stop
+	// searching
+	Accept func(Album) bool
+}
+
+// SearchHit is a candidate album together with the strategies that found it.
+type SearchHit struct {
+	Album      Album
+	Strategies []string
+}
+
+type SearchResult struct {
+	Hits []SearchHit
+	// Restricted lists albums that matched but aren't playable in the
+	// client's market. It is only filled in if nothing playable was found.
+	Restricted []Album
+	// Attempts lists every query that was tried, for diagnostics
+	Attempts []SearchAttempt
+
+	accept func(Album) bool
+}
+
+type SearchAttempt struct {
+	Strategy string `json:"strategy"`
+	Query    string `json:"query"`
+	Results  int    `json:"results"`
+	Error    string `json:"error,omitempty"`
+}
+
+type ArtistResult struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Albums returns the candidate albums in the order they were first found.
+func (r *SearchResult) Albums() []Album {
+	albums := make([]Album, len(r.Hits))
+	for i, hit := range r.Hits {
+		albums[i] = hit.Album
+	}
+	return albums
+}
+
+// StrategiesFor returns the strategies that found an album.
+func (r *SearchResult) StrategiesFor(albumID string) []string {
+	for _, hit := range r.Hits {
+		if hit.Album.ID == albumID {
+			return hit.Strategies
+		}
+	}
+	return nil
+}
+
+func (r *SearchResult) add(strategy, query string, albums []Album, err error) {
+	attempt := SearchAttempt{
+		Strategy: strategy,
+		Query:    query,
+		Results:  len(albums),
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	r.Attempts = append(r.Attempts, attempt)
+
+	for _, album := range albums {
+		found := false
+		for i := range r.Hits {
+			if r.Hits[i].Album.ID == album.ID {
+				found = true
+				if !containsString(r.Hits[i].Strategies, strategy) {
+					r.Hits[i].Strategies = append(r.Hits[i].Strategies, strategy)
+				}
+				break
+			}
+		}
+		if !found {
+			r.Hits = append(r.Hits, SearchHit{Album: album, Strategies: []string{strategy}})
+		}
+	}
+}
+
+// done reports whether searching can stop: an accepted album was found, or
+// the queries ran out.
+func (r *SearchResult) done() bool {
+	if len(r.Attempts) >= maxSearchQueries {
+		return true
+	}
+	if r.accept == nil {
+		return false
+	}
+	for _, hit := range r.Hits {
+		if r.accept(hit.Album) {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchAlbums runs the search strategies in turn and merges their
+// candidates, de-duplicated by album ID:
+//
+//   - keywords: free-text queries
+//   - fielded: album:"…" artist:"…"
+//   - year: the fielded query constrained to the release year
+//   - title+artist-id: album:"…" filtered to albums by the matching artist
+//   - discography: every album of the matching artist
+//
+// Searching stops as soon as a query finds an album req.Accept approves of,
+// or after maxSearchQueries queries.
+func (c *Client) SearchAlbums(ctx context.Context, req SearchRequest) (*SearchResult, error) {
+	if !c.Authenticated() {
+		return nil, fmt.Errorf("not authenticated - access token required")
+	}
+
+	result := &SearchResult{accept: req.Accept}
+	search := func(strategy, query string) {
+		if result.done() {
+			return
+		}
+		albums, err := c.performSearch(ctx, query, c.Market)
+		albums = FilterPlayable(albums)
+		result.add(strategy, query, albums, err)
+		c.logAttempt(strategy, query, len(albums), err)
+	}
+
+	for _, query := range req.Keywords {
+		search(StrategyKeywords, query)
+	}
+
+	for _, title := range req.Titles {
+		for _, artist := range req.Artists {
+			search(StrategyFielded, fieldedQuery(title, artist))
+		}
+	}
+
+	if req.Year > 0 && len(req.Titles) > 0 && len(req.Artists) > 0 {
+		search(StrategyYear, fmt.Sprintf("%s year:%d", fieldedQuery(req.Titles[0], req.Artists[0]), req.Year))
+	}
+
+	// The remaining strategies need to know who the artist is on Spotify
+	if !result.done() {
+		if artistIDs := c.findArtistIDs(ctx, req.Artists); len(artistIDs) > 0 {
+			c.searchByArtist(ctx, req.Titles, artistIDs, result)
+		}
+	}
+
+	c.findRestricted(ctx, req, result)
+	return result, nil
+}
+
+func (c *Client) searchByArtist(ctx context.Context, titles, artistIDs []string, result *SearchResult) {
+	for _, title := range titles {
+		if result.done() {
+			return
+		}
+		query := fmt.Sprintf(`album:"%s"`, quote(title))
+		albums, err := c.performSearch(ctx, query, c.Market)
+
+		var byArtist []Album
+		for _, album := range albums {
+			if album.hasArtist(artistIDs) && album.IsPlayable() {
+				byArtist = append(byArtist, album)
+			}
+		}
+		result.add(StrategyTitleArtist, query, byArtist, err)
+		c.logAttempt(StrategyTitleArtist, query, len(byArtist), err)
+	}
+
+	for _, artistID := range artistIDs {
+		if result.done() {
+			return
+		}
+		albums, err := c.GetArtistAlbums(ctx, artistID)
+		albums = FilterPlayable(albums)
+		result.add(StrategyDiscography, "artist:"+artistID, albums, err)
+		c.logAttempt(StrategyDiscography, "artist:"+artistID, len(albums), err)
+	}
+}
+
+// findRestricted repeats the keyword searches without a market when nothing
+// playable was found, to tell "not on Spotify" apart from "not in this market".
+func (c *Client) findRestricted(ctx context.Context, req SearchRequest, result *SearchResult) {
+	if len(result.Hits) > 0 || c.Market == "" {
+		return
+	}
+
+	for _, query := range req.Keywords {
+		albums, err := c.performSearch(ctx, query, "")
+		if err != nil {
+			continue
+		}
+		for _, album := range albums {
+			if !containsString(album.AvailableMarkets, c.Market) {
+				result.Restricted = append(result.Restricted, album)
+			}
+		}
+	}
+}
+
+func (c *Client) logAttempt(strategy, query string, results int, err error) {
+	switch {
+	case err != nil:
+		c.printf("   ❌ %s: %s (%v)\n", strategy, query, err)
+	case results == 0:
+		c.printf("   ⚠️  %s: %s (no results)\n", strategy, query)
+	default:
+		c.printf("   ✅ %s: %s (%d albums)\n", strategy, query, results)
+	}
+}
+
+// findArtistIDs looks up the Spotify artists whose name matches one of names.
+func (c *Client) findArtistIDs(ctx context.Context, names []string) []string {
+	var ids []string
+
+	for _, name := range names {
+		artists, err := c.SearchArtists(ctx, fmt.Sprintf(`artist:"%s"`, quote(name)))
+		if err != nil {
+			continue
+		}
+
+		for _, artist := range artists {
+			if normalize.Key(artist.Name) == normalize.Key(name) && !containsString(ids, artist.ID) {
+				ids = append(ids, artist.ID)
+				break
+			}
+		}
+	}
+
+	return ids
+}
+
+func (c *Client) SearchArtists(ctx context.Context, query string) ([]ArtistResult, error) {
+	if !c.Authenticated() {
+		return nil, fmt.Errorf("not authenticated - access token required")
+	}
+
+	params := url.Values{}
+	params.Add("q", query)
+	params.Add("type", "artist")
+	params.Add("limit", "5")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.spotify.com/v1/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create search request: %w", err)
+	}
+
+	c.authorize(req)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search artists: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("search request failed with status: %d", resp.StatusCode)
+	}
+
+	var searchResp struct {
+		Artists struct {
+			Items []ArtistResult `json:"items"`
+		} `json:"artists"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
+		return nil, fmt.Errorf("failed to decode search response: %w", err)
+	}
+
+	return searchResp.Artists.Items, nil
+}
+
+// performSearch searches albums in the given market, or in all markets if
+// market is empty.
+func (c *Client) performSearch(ctx context.Context, query, market string) ([]Album, error) {
+	params := url.Values{}
+	params.Add("q", query)
+	params.Add("type", "album")
+	params.Add("limit", fmt.Sprint(searchPageSize))
+	if market != "" {
+		params.Add("market", market)
+	}
+
+	return getPaged[Album](ctx, c, "https://api.spotify.com/v1/search?"+params.Encode(), "albums", "search", searchMaxResults)
+}
+
+func (a *Album) hasArtist(artistIDs []string) bool {
+	for _, artist := range a.Artists {
+		if containsString(artistIDs, artist.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldedQuery(title, artist string) string {
+	return fmt.Sprintf(`album:"%s" artist:"%s"`, quote(title), quote(artist))
+}
+
+// TrackQuery returns a SearchTracks query for a track by title and artist.
+func TrackQuery(title, artist string) string {
+	return fmt.Sprintf(`track:"%s" artist:"%s"`, quote(title), quote(artist))
+}
+
+// quote makes a value safe to put between double quotes in a search query.
+func quote(value string) string {
+	return strings.ReplaceAll(value, `"`, "")
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
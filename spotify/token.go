@@ -0,0 +1,124 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// tokenRefreshMargin is how long before it expires the access token is
+// refreshed.
+const tokenRefreshMargin = 5 * time.Minute
+
+// Authenticated reports whether the client has an access token.
+func (c *Client) Authenticated() bool {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	return c.AccessToken != ""
+}
+
+// RefreshAccessToken gets a new access token using the refresh token and
+// saves it.
+func (c *Client) RefreshAccessToken(ctx context.Context) error {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	return c.refreshAccessToken(ctx)
+}
+
+// authorize sets the Authorization header of an API request, refreshing the
+// access token first if it is about to expire.
+func (c *Client) authorize(req *http.Request) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.RefreshToken != "" && time.Now().Add(tokenRefreshMargin).After(c.ExpiresAt) {
+		if err := c.refreshAccessToken(req.Context()); err != nil {
+			c.printf("⚠️  Warning: Could not refresh Spotify token: %v\n", err)
+		}
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
+}
+
+// MissingScopes returns the scopes among required that the login doesn't
+// grant. Logging in again asks for all of Scopes.
+func (c *Client) MissingScopes(required ...string) []string {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	granted := strings.Fields(c.Scope)
+
+	var missing []string
+	for _, scope := range required {
+		if !containsString(granted, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+func (c *Client) refreshAccessToken(ctx context.Context) error {
+	if c.RefreshToken == "" {
+		return fmt.Errorf("no refresh token, log in again")
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", "refresh_token")
+	data.Set("refresh_token", c.RefreshToken)
+
+	if err := c.requestToken(ctx, data); err != nil {
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// requestToken requests a token from the Spotify accounts service and stores
+// it. The caller must hold tokenMu.
+func (c *Client) requestToken(ctx context.Context, data url.Values) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://accounts.spotify.com/api/token", strings.NewReader(data.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create token request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.ClientID, c.ClientSecret)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("token request failed with status: %d", resp.StatusCode)
+	}
+
+	var tokenResp TokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	c.AccessToken = tokenResp.AccessToken
+	c.ExpiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	// Refreshing doesn't always return a new refresh token
+	if tokenResp.RefreshToken != "" {
+		c.RefreshToken = tokenResp.RefreshToken
+	}
+	if tokenResp.Scope != "" {
+		c.Scope = tokenResp.Scope
+	}
+
+	// Save token for future use
+	if err := c.saveToken(); err != nil {
+		c.printf("Warning: Failed to save token: %v\n", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package stats
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Report formats.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+var funcs = map[string]interface{}{
+	"percent": func(rate float64) string {
+		return fmt.Sprintf("%.0f%%", rate*100)
+	},
+	"inc": func(i int) int {
+		return i + 1
+	},
+	"date": func(t time.Time) string {
+		return t.Local().Format("2006-01-02")
+	},
+	// cell escapes the characters that break a Markdown table cell
+	"cell": func(s string) string {
+		return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+	},
+}
+
+const markdownReport = `# {{.Title}}
+
+**{{.Scans}} scans**, {{.AlbumCount}} different albums, {{.Failed}} failed ({{percent .FailureRate}}).
+{{if .Albums}}
+## Most played albums
+
+| # | Album | Artist | Scans |
+| - | ----- | ------ | ----- |
+{{range $i, $c := .Albums}}| {{inc $i}} | {{cell $c.Name}} | {{cell $c.Artist}} | {{$c.Count}} |
+{{end}}{{end}}{{if .Artists}}
+## Most played artists
+
+| # | Artist | Scans |
+| - | ------ | ----- |
+{{range $i, $c := .Artists}}| {{inc $i}} | {{cell $c.Name}} | {{$c.Count}} |
+{{end}}{{end}}{{if .Weeks}}
+## Scans per week
+
+| Week | Scans | Failed |
+| ---- | ----- | ------ |
+{{range .Weeks}}| {{.Period}} | {{.Scans}} | {{.Failed}} |
+{{end}}{{end}}{{if .Failures}}
+## Failures by cause
+
+| Cause | Scans |
+| ----- | ----- |
+{{range .Failures}}| {{.Name}} | {{.Count}} |
+{{end}}{{end}}{{if .Unplayed}}
+## Not played in {{.StaleDays}} days
+
+{{range .Unplayed}}- {{.Name}} · {{.Artist}} ({{if .Plays}}last played {{date .LastPlayed}}{{else}}never played{{end}})
+{{end}}{{end}}`
+
+const htmlReport = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 0 auto; max-width: 48rem; padding: 1rem; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5rem; }
+th, td { text-align: left; padding: 0.25rem 0.75rem; border-bottom: 1px solid #ddd; }
+.summary { font-size: 1.1rem; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="summary"><strong>{{.Scans}} scans</strong>, {{.AlbumCount}} different albums, {{.Failed}} failed ({{percent .FailureRate}}).</p>
+{{if .Albums}}
+<h2>Most played albums</h2>
+<table>
+<tr><th>#</th><th>Album</th><th>Artist</th><th>Scans</th></tr>
+{{range $i, $c := .Albums}}<tr><td>{{inc $i}}</td><td>{{$c.Name}}</td><td>{{$c.Artist}}</td><td>{{$c.Count}}</td></tr>
+{{end}}</table>
+{{end}}{{if .Artists}}
+<h2>Most played artists</h2>
+<table>
+<tr><th>#</th><th>Artist</th><th>Scans</th></tr>
+{{range $i, $c := .Artists}}<tr><td>{{inc $i}}</td><td>{{$c.Name}}</td><td>{{$c.Count}}</td></tr>
+{{end}}</table>
+{{end}}{{if .Weeks}}
+<h2>Scans per week</h2>
+<table>
+<tr><th>Week</th><th>Scans</th><th>Failed</th></tr>
+{{range .Weeks}}<tr><td>{{.Period}}</td><td>{{.Scans}}</td><td>{{.Failed}}</td></tr>
+{{end}}</table>
+{{end}}{{if .Failures}}
+<h2>Failures by cause</h2>
+<table>
+<tr><th>Cause</th><th>Scans</th></tr>
+{{range .Failures}}<tr><td>{{.Name}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+{{end}}{{if .Unplayed}}
+<h2>Not played in {{.StaleDays}} days</h2>
+<ul>
+{{range .Unplayed}}<li>{{.Name}} · {{.Artist}} ({{if .Plays}}last played {{date .LastPlayed}}{{else}}never played{{end}})</li>
+{{end}}</ul>
+{{end}}
+</body>
+</html>
+`
+
+// report is what the report templates are rendered with.
+type report struct {
+	*Stats
+	Title     string
+	StaleDays int
+}
+
+// WriteReport renders the statistics as a Markdown or HTML report.
+func WriteReport(w io.Writer, s *Stats, format, title string, staleAfter time.Duration) error {
+	if staleAfter == 0 {
+		staleAfter = DefaultStaleAfter
+	}
+	data := report{Stats: s, Title: title, StaleDays: int(staleAfter.Hours() / 24)}
+
+	switch format {
+	case FormatMarkdown:
+		tmpl := template.Must(template.New("report").Funcs(funcs).Parse(markdownReport))
+		return tmpl.Execute(w, data)
+	case FormatHTML:
+		tmpl := htmltemplate.Must(htmltemplate.New("report").Funcs(funcs).Parse(htmlReport))
+		return tmpl.Execute(w, data)
+	}
+
+	return fmt.Errorf("unknown report format %q, expected %s or %s", format, FormatMarkdown, FormatHTML)
+}
+
+// Title describes the period of a report, such as "September 2026".
+func Title(since, until time.Time) string {
+	switch {
+	case !since.IsZero() && until.Equal(since.AddDate(0, 1, 0)) && since.Day() == 1:
+		return "Listening report for " + since.Format("January 2006")
+	case !since.IsZero() && !until.IsZero():
+		return fmt.Sprintf("Listening report for %s to %s", since.Format("2006-01-02"), until.AddDate(0, 0, -1).Format("2006-01-02"))
+	case !since.IsZero():
+		return "Listening report since " + since.Format("2006-01-02")
+	case !until.IsZero():
+		return "Listening report until " + until.AddDate(0, 0, -1).Format("2006-01-02")
+	}
+	return "Listening report"
+}
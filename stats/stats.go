@@ -0,0 +1,334 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"barcode-music-player/collection"
+	"barcode-music-player/history"
+)
+
+// DefaultStaleAfter is how long an album must not have been played to be
+// listed as forgotten.
+const DefaultStaleAfter = 180 * 24 * time.Hour
+
+// Options select the period the statistics cover.
+type Options struct {
+	// Since and Until bound the period; zero values leave it open
+	Since time.Time
+	Until time.Time
+	// Top is how many albums and artists to list
+	Top int
+	// StaleAfter is how long ago an album must last have been played to be
+	// listed in Unplayed
+	StaleAfter time.Duration
+	// Now is the time Unplayed is relative to
+	Now time.Time
+	// Collection is the releases owned: those not played within StaleAfter,
+	// including the ones never played, are listed in Unplayed too
+	Collection []collection.Item
+}
+
+// Stats summarizes the scans of a period.
+type Stats struct {
+	Since *time.Time `json:"since,omitempty"`
+	Until *time.Time `json:"until,omitempty"`
+
+	// Scans counts album scans: commands, catalog scans and dry runs are
+	// left out
+	Scans       int     `json:"scans"`
+	Failed      int     `json:"failed"`
+	FailureRate float64 `json:"failure_rate"`
+	Commands    int     `json:"commands"`
+	Cataloged   int     `json:"cataloged"`
+
+	// AlbumCount is how many different albums were played
+	AlbumCount int     `json:"album_count"`
+	Albums     []Count `json:"albums"`
+	Artists    []Count `json:"artists"`
+	// Failures counts failed scans by outcome, most frequent first
+	Failures []Count  `json:"failures"`
+	Days     []Period `json:"days"`
+	Weeks    []Period `json:"weeks"`
+
+	// Unplayed lists albums, and releases in the collection, not played
+	// within StaleAfter of Now, whatever the period. Never played ones come
+	// first.
+	Unplayed []Album `json:"unplayed"`
+}
+
+// Count is how often an album, artist or outcome came up.
+type Count struct {
+	Name     string `json:"name"`
+	Artist   string `json:"artist,omitempty"`
+	AlbumURI string `json:"album_uri,omitempty"`
+	Count    int    `json:"count"`
+}
+
+// Period is a day (2006-01-02) or an ISO week (2006-W01).
+type Period struct {
+	Period string `json:"period"`
+	Scans  int    `json:"scans"`
+	Failed int    `json:"failed"`
+}
+
+// Album is an album, and when it last was played if it ever was.
+type Album struct {
+	Name     string `json:"name"`
+	Artist   string `json:"artist"`
+	AlbumURI string `json:"album_uri,omitempty"`
+	// Barcode is set for releases in the collection
+	Barcode    string    `json:"barcode,omitempty"`
+	LastPlayed time.Time `json:"last_played,omitzero"`
+	Plays      int       `json:"plays"`
+}
+
+// Compute summarizes history entries, given oldest first.
+func Compute(entries []history.Entry, opts Options) *Stats {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if opts.StaleAfter == 0 {
+		opts.StaleAfter = DefaultStaleAfter
+	}
+
+	s := &Stats{}
+	if !opts.Since.IsZero() {
+		s.Since = &opts.Since
+	}
+	if !opts.Until.IsZero() {
+		s.Until = &opts.Until
+	}
+	period := history.Filter{Since: opts.Since, Until: opts.Until}
+
+	albums := newCounter()
+	artists := newCounter()
+	failures := newCounter()
+	days := newCounter()
+	weeks := newCounter()
+	played := make(map[string]*Album)
+
+	for _, e := range entries {
+		if e.DryRun {
+			continue
+		}
+
+		if e.Played() && e.Album != "" {
+			key := albumKey(e)
+			album, ok := played[key]
+			if !ok {
+				album = &Album{Name: e.Album, Artist: e.Artist, AlbumURI: e.AlbumURI}
+				played[key] = album
+			}
+			album.Plays++
+			if e.Time.After(album.LastPlayed) {
+				album.LastPlayed = e.Time
+			}
+		}
+
+		if !period.Match(e) {
+			continue
+		}
+		switch e.Outcome {
+		case history.OutcomeCommand:
+			s.Commands++
+			continue
+		case history.OutcomeCataloged:
+			s.Cataloged++
+			continue
+		}
+
+		s.Scans++
+		day := e.Time.Local().Format("2006-01-02")
+		year, week := e.Time.Local().ISOWeek()
+		weekKey := fmt.Sprintf("%d-W%02d", year, week)
+		days.add(day, Count{Name: day})
+		weeks.add(weekKey, Count{Name: weekKey})
+
+		if e.Failed() {
+			s.Failed++
+			failures.add(e.Outcome, Count{Name: e.Outcome})
+			days.fail(day)
+			weeks.fail(weekKey)
+			continue
+		}
+
+		// Pausing or skipping the album already playing isn't a play
+		if !e.Played() {
+			continue
+		}
+
+		if e.Album != "" {
+			albums.add(albumKey(e), Count{Name: e.Album, Artist: e.Artist, AlbumURI: e.AlbumURI})
+		}
+		if e.Artist != "" {
+			artists.add(e.Artist, Count{Name: e.Artist})
+		}
+	}
+
+	if s.Scans > 0 {
+		s.FailureRate = float64(s.Failed) / float64(s.Scans)
+	}
+
+	s.AlbumCount = len(albums.keys)
+	s.Albums = albums.top(opts.Top)
+	s.Artists = artists.top(opts.Top)
+	s.Failures = failures.top(0)
+	s.Days = days.periods()
+	s.Weeks = weeks.periods()
+
+	unplayed := neverPlayed(played, opts.Collection)
+
+	cutoff := opts.Now.Add(-opts.StaleAfter)
+	for _, album := range played {
+		if album.LastPlayed.Before(cutoff) {
+			unplayed = append(unplayed, *album)
+		}
+	}
+	sort.SliceStable(unplayed, func(i, j int) bool {
+		return unplayed[i].LastPlayed.Before(unplayed[j].LastPlayed)
+	})
+	s.Unplayed = unplayed
+
+	return s
+}
+
+// MonthRange returns the start of the month given as 2006-01, and the start
+// of the next month.
+func MonthRange(month string, loc *time.Location) (since, until time.Time, err error) {
+	since, err = time.ParseInLocation("2006-01", month, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid month %q, expected YYYY-MM", month)
+	}
+	return since, since.AddDate(0, 1, 0), nil
+}
+
+// PreviousMonth returns the month before now's, as 2006-01. Stepping back
+// from the first of the month keeps the 31st from overflowing into now's
+// month.
+func PreviousMonth(now time.Time) string {
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	return first.AddDate(0, -1, 0).Format("2006-01")
+}
+
+// ParsePeriod parses the period of the stats and history commands: a month
+// (2006-01), or since and until as accepted by history.ParseTime. Empty
+// values leave the period open.
+func ParsePeriod(since, until, month string, now time.Time) (time.Time, time.Time, error) {
+	if month != "" {
+		if since != "" || until != "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("a month can't be combined with since or until")
+		}
+		return MonthRange(month, now.Location())
+	}
+
+	var start, end time.Time
+	var err error
+	if since != "" {
+		if start, err = history.ParseTime(since, now); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if until != "" {
+		if end, err = history.ParseTime(until, now); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	return start, end, nil
+}
+
+// neverPlayed returns the collection items that match none of the played
+// albums, by Spotify URI or by name. The played albums matched get the
+// item's barcode.
+func neverPlayed(played map[string]*Album, items []collection.Item) []Album {
+	byName := make(map[string]*Album, len(played))
+	for _, album := range played {
+		byName[nameKey(album.Name, album.Artist)] = album
+	}
+
+	unplayed := []Album{}
+	for _, item := range items {
+		album, ok := played[item.AlbumURI]
+		if !ok {
+			album, ok = byName[nameKey(item.Title, item.Artist)]
+		}
+		if ok {
+			album.Barcode = item.Barcode
+			continue
+		}
+		unplayed = append(unplayed, Album{Name: item.Title, Artist: item.Artist, AlbumURI: item.AlbumURI, Barcode: item.Barcode})
+	}
+	return unplayed
+}
+
+func nameKey(name, artist string) string {
+	return strings.ToLower(name) + "\x00" + strings.ToLower(artist)
+}
+
+// albumKey identifies an album by its Spotify URI, or by its name for
+// tracks played without a Spotify album.
+func albumKey(e history.Entry) string {
+	if e.AlbumURI != "" {
+		return e.AlbumURI
+	}
+	return e.Album + "\x00" + e.Artist
+}
+
+// counter counts occurrences by key, remembering the first description and
+// failures for periods.
+type counter struct {
+	keys   []string
+	counts map[string]*Count
+	failed map[string]int
+}
+
+func newCounter() *counter {
+	return &counter{counts: make(map[string]*Count), failed: make(map[string]int)}
+}
+
+func (c *counter) add(key string, count Count) {
+	existing, ok := c.counts[key]
+	if !ok {
+		c.keys = append(c.keys, key)
+		existing = &count
+		c.counts[key] = existing
+	}
+	existing.Count++
+}
+
+func (c *counter) fail(key string) {
+	c.failed[key]++
+}
+
+// top returns the n most frequent counts, all of them if n is 0. Ties keep
+// the order they were first seen in.
+func (c *counter) top(n int) []Count {
+	counts := make([]Count, 0, len(c.keys))
+	for _, key := range c.keys {
+		counts = append(counts, *c.counts[key])
+	}
+
+	sort.SliceStable(counts, func(i, j int) bool {
+		return counts[i].Count > counts[j].Count
+	})
+
+	if n > 0 && len(counts) > n {
+		counts = counts[:n]
+	}
+	return counts
+}
+
+// periods returns the counts as periods in chronological order.
+func (c *counter) periods() []Period {
+	periods := make([]Period, 0, len(c.keys))
+	for _, key := range c.keys {
+		periods = append(periods, Period{Period: key, Scans: c.counts[key].Count, Failed: c.failed[key]})
+	}
+
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].Period < periods[j].Period
+	})
+	return periods
+}
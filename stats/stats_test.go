@@ -0,0 +1,187 @@
+package stats
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"barcode-music-player/collection"
+	"barcode-music-player/history"
+)
+
+func at(year int, month time.Month, day, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, time.Local)
+}
+
+func played(t time.Time, album, artist, uri string) history.Entry {
+	return history.Entry{Time: t, Album: album, Artist: artist, AlbumURI: uri, Outcome: history.OutcomePlayed}
+}
+
+func TestComputeFailureRate(t *testing.T) {
+	day := at(2026, time.March, 10, 12)
+	entries := []history.Entry{
+		played(day, "Kind of Blue", "Miles Davis", "spotify:album:kob"),
+		{Time: day, Outcome: history.OutcomeNotFound},
+		{Time: day, Outcome: history.OutcomeNotOnSpotify},
+		{Time: day, Outcome: history.OutcomeNotFound},
+		{Time: day, Outcome: history.OutcomeSkipped},
+		// Commands, catalog scans and dry runs aren't album scans
+		{Time: day, Outcome: history.OutcomeCommand},
+		{Time: day, Outcome: history.OutcomeCataloged},
+		{Time: day, Outcome: history.OutcomeNotFound, DryRun: true},
+	}
+
+	s := Compute(entries, Options{Now: day})
+
+	if s.Scans != 5 || s.Failed != 3 || s.Commands != 1 || s.Cataloged != 1 {
+		t.Errorf("scans = %d, failed = %d, commands = %d, cataloged = %d, want 5, 3, 1, 1",
+			s.Scans, s.Failed, s.Commands, s.Cataloged)
+	}
+	if math.Abs(s.FailureRate-0.6) > 1e-9 {
+		t.Errorf("failure rate = %v, want 0.6", s.FailureRate)
+	}
+	want := []Count{{Name: history.OutcomeNotFound, Count: 2}, {Name: history.OutcomeNotOnSpotify, Count: 1}}
+	if !reflect.DeepEqual(s.Failures, want) {
+		t.Errorf("failures = %+v, want %+v", s.Failures, want)
+	}
+	if s.AlbumCount != 1 {
+		t.Errorf("album count = %d, want 1", s.AlbumCount)
+	}
+
+	if s := Compute(nil, Options{Now: day}); s.FailureRate != 0 {
+		t.Errorf("failure rate without scans = %v, want 0", s.FailureRate)
+	}
+}
+
+func TestComputePeriods(t *testing.T) {
+	entries := []history.Entry{
+		// The first ISO week of 2026 starts in 2025
+		played(at(2025, time.December, 29, 12), "C", "Z", "spotify:album:c"),
+		// Sunday, the last day of ISO week 9
+		played(at(2026, time.March, 1, 23), "A", "X", "spotify:album:a"),
+		{Time: at(2026, time.March, 1, 23), Outcome: history.OutcomeFailed},
+		// Monday, the start of week 10
+		played(at(2026, time.March, 2, 0), "A", "X", "spotify:album:a"),
+		played(at(2026, time.March, 3, 9), "B", "Y", "spotify:album:b"),
+		{Time: at(2026, time.March, 3, 10), Outcome: history.OutcomeNoDevice},
+	}
+
+	s := Compute(entries, Options{Now: at(2026, time.April, 1, 0)})
+
+	wantDays := []Period{
+		{Period: "2025-12-29", Scans: 1},
+		{Period: "2026-03-01", Scans: 2, Failed: 1},
+		{Period: "2026-03-02", Scans: 1},
+		{Period: "2026-03-03", Scans: 2, Failed: 1},
+	}
+	if !reflect.DeepEqual(s.Days, wantDays) {
+		t.Errorf("days = %+v, want %+v", s.Days, wantDays)
+	}
+
+	wantWeeks := []Period{
+		{Period: "2026-W01", Scans: 1},
+		{Period: "2026-W09", Scans: 2, Failed: 1},
+		{Period: "2026-W10", Scans: 3, Failed: 1},
+	}
+	if !reflect.DeepEqual(s.Weeks, wantWeeks) {
+		t.Errorf("weeks = %+v, want %+v", s.Weeks, wantWeeks)
+	}
+}
+
+func TestComputeMonth(t *testing.T) {
+	since, until, err := MonthRange("2026-03", time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []history.Entry{
+		played(at(2026, time.February, 28, 23), "A", "X", "spotify:album:a"),
+		played(since, "B", "Y", "spotify:album:b"),
+		played(at(2026, time.March, 31, 23), "C", "Z", "spotify:album:c"),
+		played(until, "D", "W", "spotify:album:d"),
+	}
+
+	s := Compute(entries, Options{Since: since, Until: until, Now: until})
+
+	if s.Scans != 2 {
+		t.Errorf("scans = %d, want 2", s.Scans)
+	}
+	var names []string
+	for _, album := range s.Albums {
+		names = append(names, album.Name)
+	}
+	if want := []string{"B", "C"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("albums = %v, want %v", names, want)
+	}
+	if s.Since == nil || !s.Since.Equal(since) || s.Until == nil || !s.Until.Equal(until) {
+		t.Errorf("period = %v to %v, want %s to %s", s.Since, s.Until, since, until)
+	}
+}
+
+func TestComputeUnplayed(t *testing.T) {
+	now := at(2026, time.October, 1, 12)
+	entries := []history.Entry{
+		played(now.AddDate(0, -9, 0), "Old", "X", "spotify:album:old"),
+		played(now.AddDate(0, -8, 0), "Replayed", "Y", "spotify:album:replayed"),
+		played(now.AddDate(0, 0, -5), "Replayed", "Y", "spotify:album:replayed"),
+		played(now.AddDate(0, -7, 0), "Older", "Z", "spotify:album:older"),
+		played(now.AddDate(0, 0, -1), "Recent", "W", ""),
+		// Outside the period, but still counts as a play
+		played(now.AddDate(0, -1, 0), "Outside", "V", "spotify:album:outside"),
+	}
+	items := []collection.Item{
+		{Barcode: "1", Title: "Shelved", Artist: "U", AlbumURI: "spotify:album:shelved"},
+		// Matched by URI and by name
+		{Barcode: "2", Title: "Old (Remastered)", Artist: "X", AlbumURI: "spotify:album:old"},
+		{Barcode: "3", Title: "recent", Artist: "w"},
+	}
+
+	s := Compute(entries, Options{
+		Since:      now.AddDate(0, 0, -7),
+		Now:        now,
+		StaleAfter: 90 * 24 * time.Hour,
+		Collection: items,
+	})
+
+	want := []Album{
+		{Name: "Shelved", Artist: "U", AlbumURI: "spotify:album:shelved", Barcode: "1"},
+		{Name: "Old", Artist: "X", AlbumURI: "spotify:album:old", Barcode: "2", LastPlayed: now.AddDate(0, -9, 0), Plays: 1},
+		{Name: "Older", Artist: "Z", AlbumURI: "spotify:album:older", LastPlayed: now.AddDate(0, -7, 0), Plays: 1},
+	}
+	if !reflect.DeepEqual(s.Unplayed, want) {
+		t.Errorf("unplayed = %+v, want %+v", s.Unplayed, want)
+	}
+}
+
+func TestComputeUnplayedDefaultStaleAfter(t *testing.T) {
+	now := at(2026, time.October, 1, 12)
+	entries := []history.Entry{
+		played(now.Add(-DefaultStaleAfter-time.Hour), "Stale", "X", "spotify:album:stale"),
+		played(now.Add(-DefaultStaleAfter+time.Hour), "Fresh", "Y", "spotify:album:fresh"),
+	}
+
+	s := Compute(entries, Options{Now: now})
+	if len(s.Unplayed) != 1 || s.Unplayed[0].Name != "Stale" {
+		t.Errorf("unplayed = %+v, want only Stale", s.Unplayed)
+	}
+}
+
+func TestPreviousMonth(t *testing.T) {
+	tests := []struct {
+		now  time.Time
+		want string
+	}{
+		{time.Date(2026, time.March, 31, 12, 0, 0, 0, time.UTC), "2026-02"},
+		{time.Date(2026, time.March, 29, 0, 0, 0, 0, time.UTC), "2026-02"},
+		{time.Date(2026, time.May, 31, 23, 59, 0, 0, time.UTC), "2026-04"},
+		{time.Date(2026, time.January, 31, 8, 0, 0, 0, time.UTC), "2025-12"},
+		{time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC), "2026-09"},
+	}
+
+	for _, tt := range tests {
+		if got := PreviousMonth(tt.now); got != tt.want {
+			t.Errorf("PreviousMonth(%s) = %s, want %s", tt.now.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}